@@ -0,0 +1,83 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+)
+
+// ServeCommands accepts connections on the listener and handles each connection in a separate goroutine.
+// Every line received is split into arguments, parsed and dispatched with the registered commands and options just
+// like ParseArgs, and help and errors are sent back to the client. Commands are run one at a time, so a slow command
+// holds up the other connections, and each command line starts from the same option values. Commands added with
+// CommandContext reach the client by writing to the Output of ResultFromContext, Output() is left as the output of
+// the program.
+//
+// Command lines from clients can only set options and run commands. Built-in flags other than help, the config
+// command, response files, run profiles and @file values are rejected, so a client cannot read or change files of
+// the server. A panic in a command is sent to the client as an error instead of stopping the server. Access control
+// is left to the listener, use a unix socket with restricted permissions or bind to localhost.
+//
+//	go cmdparse.ListenAndServeCommands("unix", "/var/run/myapp.sock")
+//
+//	cmdparse.CommandContext("status", "", func(ctx context.Context) {
+//	  fmt.Fprintln(cmdparse.ResultFromContext(ctx).Output, "running")
+//	})
+func ServeCommands(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go serveConnection(conn)
+	}
+}
+
+// ListenAndServeCommands listens on the network address and calls ServeCommands to handle incoming command lines.
+// Network is "unix" for a unix socket path or "tcp" for an address like "127.0.0.1:9000".
+func ListenAndServeCommands(network string, address string) error {
+	l, err := net.Listen(network, address)
+	if err != nil {
+		return err
+	}
+	defer l.Close()
+	return ServeCommands(l)
+}
+
+func serveConnection(conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		if err := serveLine(conn, scanner.Text()); err != nil && err != ErrHelp {
+			if _, err := fmt.Fprintf(conn, messages.Error+"\n", err.Error()); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// serveLine parses and dispatches a command line received from a client, a panic in the command is returned as an
+// error so that it does not stop the server
+func serveLine(w io.Writer, line string) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+	args, err := SplitCommandLine(line)
+	if err != nil || len(args) == 0 {
+		return err
+	}
+	_, err = parseIsolated(args, w, parseRemote)
+	return err
+}
@@ -0,0 +1,112 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GenerateAlias returns a snippet for shell that defines name as the program with presetArgs, for the user to add to
+// the startup file of the shell. Arguments following the alias on the commandline are added after presetArgs. The
+// options in presetArgs and their values are checked like on the commandline, so that a mistyped option is reported
+// when the alias is generated instead of every time it is used. Shell is one of sh, bash, zsh, fish, powershell and
+// cmd.
+//
+//	snippet, err := cmdparse.GenerateAlias("bash", "prodtool", []string{"-server=prod:443"})
+//	// alias prodtool='mytool -server=prod:443'
+func GenerateAlias(shell string, name string, presetArgs []string) (string, error) {
+	parseMutex.Lock()
+	defer parseMutex.Unlock()
+	invalidateIndex()
+
+	presetArgs = normalizeArgs(presetArgs)
+	if err := checkArgs(presetArgs); err != nil {
+		return "", err
+	}
+	var quote func(string) string
+	switch shell {
+	case "sh", "bash", "zsh", "fish":
+		quote = quotePOSIX
+	case "powershell", "pwsh":
+		quote = quotePowerShell
+	case "cmd":
+		quote = quoteCmd
+	default:
+		return "", fmt.Errorf(messages.UnsupportedShell, shell)
+	}
+	words := []string{quote(commandName)}
+	for _, a := range presetArgs {
+		words = append(words, quote(a))
+	}
+	line := strings.Join(words, " ")
+
+	switch shell {
+	case "fish":
+		return fmt.Sprintf("function %s; %s $argv; end", name, line), nil
+	case "powershell", "pwsh":
+		return fmt.Sprintf("function %s { %s @args }", name, line), nil
+	case "cmd":
+		return fmt.Sprintf("doskey %s=%s $*", name, line), nil
+	}
+	return fmt.Sprintf("alias %s=%s", name, quotePOSIX(line)), nil
+}
+
+// checkArgs returns an error for the first unknown option or invalid option value in args, without changing any
+// option values
+func checkArgs(args []string) error {
+	var positional []string
+	for i := 0; i < len(args); i++ {
+		flag, ok := optionName(args[i])
+		if args[i] == "--" {
+			return nil
+		} else if !ok {
+			positional = append(positional, args[i])
+			continue
+		}
+		pair := strings.SplitN(flag, "=", 2)
+		o := findScopedOption(pair[0], matchCommand(positional))
+		if o == nil {
+			return fmt.Errorf(messages.InvalidOption, displayPrefix+pair[0])
+		}
+		if len(pair) < 2 && takesValue(o) && i < len(args)-1 && isValueArg(o, args[i+1]) {
+			i++
+			pair = append(pair, args[i])
+		}
+		if len(pair) == 2 {
+			previous := o.plainString()
+			err := o.set(pair[1])
+			o.setString(previous)
+			if err != nil {
+				return o.invalidValue(pair[0], pair[1], err)
+			}
+		}
+	}
+	return nil
+}
+
+// quotePOSIX quotes s for POSIX shells and fish if it contains anything but safe characters
+func quotePOSIX(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_@%+=:,./-") == "" {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// quotePowerShell quotes s for PowerShell if it contains anything but safe characters
+func quotePowerShell(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789_=:./\\-") == "" {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", "''", -1) + "'"
+}
+
+// quoteCmd quotes s for cmd if it contains spaces or characters that cmd interprets
+func quoteCmd(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t&|<>^()%!\"") {
+		return s
+	}
+	return `"` + strings.Replace(s, `"`, `""`, -1) + `"`
+}
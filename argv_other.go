@@ -0,0 +1,13 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package cmdparser
+
+// os.Args are copies of the process arguments on this platform, changing them does not change what others see
+const canScrubArgs = false
+
+func scrubString(s string) {}
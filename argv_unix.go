@@ -0,0 +1,25 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package cmdparser
+
+import (
+	"reflect"
+	"unsafe"
+)
+
+// The strings of os.Args point directly to the argument memory of the process, which is what ps shows
+const canScrubArgs = true
+
+// scrubString overwrites the bytes of a string from os.Args with *
+func scrubString(s string) {
+	header := (*reflect.StringHeader)(unsafe.Pointer(&s))
+	b := (*[1 << 30]byte)(unsafe.Pointer(header.Data))[:len(s):len(s)]
+	for i := range b {
+		b[i] = '*'
+	}
+}
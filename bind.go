@@ -0,0 +1,127 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Bind walks the fields of a struct and adds an option for every field tagged with a cmd name. This way a large
+// configuration can be declared in one annotated struct instead of calling an *Option function for every option.
+// Supported field types are bool, *bool, int64, float64, string, *string, []string, []int64, []float64 and []byte.
+// Embedded structs are walked as well.
+//
+// The following tags are recognized
+//
+//	cmd:"name"                     name of the option (required to bind the field)
+//	group:"command"                command group, blank for global options
+//	format:"<ip>:<port>"           format text shown in help
+//	help:"Server address"          help text
+//	default:"localhost:80"         default value, parsed the same way as on the commandline
+//	flags:"preference,required"    comma separated list of standard, preference, required, hidden, sensitive and masked
+//
+// Example
+//
+//	var config struct {
+//	  Server  string `cmd:"server" format:"<ip>:<port>" help:"Server address" flags:"preference,required"`
+//	  Verbose bool   `cmd:"verbose" help:"Show verbose output"`
+//	}
+//	if err := cmdparse.Bind(&config); err != nil {
+//	  panic(err)
+//	}
+func Bind(structPtr interface{}) error {
+	v := reflect.ValueOf(structPtr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return errors.New(messages.BindNotStruct)
+	}
+	return bindStruct(v.Elem())
+}
+
+func bindStruct(v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, tagged := field.Tag.Lookup("cmd")
+		if !tagged {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := bindStruct(v.Field(i)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			return fmt.Errorf(messages.BindUnexported, field.Name)
+		}
+
+		flags, err := parseFlagsTag(field.Tag.Get("flags"))
+		if err != nil {
+			return fmt.Errorf(messages.BindInvalidFlags, field.Name, err.Error())
+		}
+
+		var value Value
+		switch p := v.Field(i).Addr().Interface().(type) {
+		case *bool:
+			value = (*boolOption)(p)
+		case **bool:
+			value = boolPtrOption{p}
+		case *int64:
+			value = (*intOption)(p)
+		case *float64:
+			value = (*floatOption)(p)
+		case *string:
+			value = (*stringOption)(p)
+		case **string:
+			value = stringPtrOption{p}
+		case *[]string:
+			value = (*stringListOption)(p)
+		case *[]int64:
+			value = (*intListOption)(p)
+		case *[]float64:
+			value = (*floatListOption)(p)
+		case *[]byte:
+			value = (*byteOption)(p)
+		default:
+			return fmt.Errorf(messages.BindUnsupportedType, field.Name, field.Type)
+		}
+		if def, ok := field.Tag.Lookup("default"); ok {
+			if err := value.Set(def); err != nil {
+				return fmt.Errorf(messages.BindInvalidDefault, field.Name, def, err.Error())
+			}
+		}
+		addOption(name, field.Tag.Get("group"), field.Tag.Get("format"), field.Tag.Get("help"), value, flags)
+	}
+	return nil
+}
+
+func parseFlagsTag(tag string) (int, error) {
+	flags := 0
+	for _, f := range strings.Split(tag, ",") {
+		switch strings.TrimSpace(strings.ToLower(f)) {
+		case "":
+		case "standard":
+			flags |= Standard
+		case "preference":
+			flags |= Preference
+		case "required":
+			flags |= Required
+		case "hidden":
+			flags |= Hidden
+		case "sensitive":
+			flags |= Sensitive
+		case "masked":
+			flags |= Masked
+		default:
+			return 0, fmt.Errorf(messages.BindUnknownFlag, f)
+		}
+	}
+	if flags == 0 {
+		flags = Standard
+	}
+	return flags, nil
+}
@@ -6,14 +6,24 @@
 package cmdparser
 
 import (
+	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Flags to commandline options
@@ -22,20 +32,126 @@ const (
 	Preference             // Preference option that is saved and loaded with options file
 	Required               // Required option
 	Hidden                 // Hidden option not shown in help
+	Sensitive              // Sensitive option like a password or key that is masked like Masked and never stored in run profiles
+	Masked                 // Option value is shown as ******** by -showoptions, -showconfig and help, but saved as is
+	Locked                 // Option cannot be changed from the options file or commandline, see PolicyFile
 )
 
-// Args array will contain all arguments that were not parsed
+const maskedValue = "********" // Displayed instead of the value of Masked and Sensitive options
+
+// Args array will contain all arguments that were not parsed, not including the program and command name
 var Args []string
 
-// Title sets the text to be printed at the top of help. Setting title also enables the -version flag that will display the Title string.
+var rawArgs []string
+
+var interspersed = true
+
+// SetInterspersed controls if options can be mixed with the command and its arguments, which is the default.
+// With SetInterspersed(false) option parsing stops at the first argument that is not an option, normally the command
+// name, and everything following it is left in Args for the command to handle, including arguments starting with -.
+//
+//	cmdparse.SetInterspersed(false)
+//
+//	mytool -verbose run -it image -v    (Args of run are -it, image and -v)
+func SetInterspersed(allow bool) {
+	interspersed = allow
+}
+
+var strictBool bool
+
+// SetStrictBool makes bool options only take a value attached with =, like -verbose=false, as in the standard flag
+// package. By default a bool option also takes the following argument as its value if it is a bool, so mytool
+// -verbose 1 would use the positional argument 1 as the value of -verbose.
+//
+//	cmdparse.SetStrictBool(true)
+func SetStrictBool(strict bool) {
+	strictBool = strict
+}
+
+var caseInsensitiveCommands bool
+
+// SetCaseInsensitiveCommands makes command names match regardless of case, so that mytool COPY runs the copy command.
+// Option names are not affected.
+//
+//	cmdparse.SetCaseInsensitiveCommands(true)
+func SetCaseInsensitiveCommands(insensitive bool) {
+	caseInsensitiveCommands = insensitive
+}
+
+// commandWord returns true if the argument arg names the command word w
+func commandWord(arg string, w string) bool {
+	return arg == w || (caseInsensitiveCommands && strings.EqualFold(arg, w))
+}
+
+// RawArgs returns exactly the arguments following the -- terminator, or nil if there was no terminator. The arguments
+// are also included in Args, but are never parsed as options or matched as a command name.
+//
+//	mytool exec -verbose -- ls -la
+func RawArgs() []string {
+	return rawArgs
+}
+
+// Title sets the text to be printed at the top of help. Setting title also enables the -version flag that will display the Title string
+// unless a version has been set with SetVersion.
 var Title string
 
-// OptionsFile sets the filename (with full path) where to load and save preference options. Setting OptionsFile enables -showoptions and -saveoptions flags.
+// OptionsFile sets the filename (with full path) where to load and save preference options. Setting OptionsFile enables -showoptions, -saveoptions, -validateoptions and -editoptions flags.
+// It also enables a config command with get <name>, set <name> <value>, unset <name> and list, that reads and
+// changes single Preference options in the options file, unless the application has added a config command itself.
+// Keys in the options file that only differ from an option in case, dashes or underscores, like "Log_Level" for
+// log-level, are read as that option with a WarningNormalizedKey warning, as hand-edited files often get them wrong.
 var OptionsFile string
 
-var commandName string        // Name of command to use in Usage instructions
-var commandList []*CmdCommand // Internal list of all commands
-var optionList []*CmdOption   // Internal list of all options
+// DefaultOptionsFile returns the conventional path of the options file for an application, options.json in a folder
+// named after the application in UserConfigFolder.
+//
+//	cmdparse.OptionsFile = cmdparse.DefaultOptionsFile("mytool")
+func DefaultOptionsFile(appName string) string {
+	return filepath.Join(UserConfigFolder(), appName, "options.json")
+}
+
+// AllowFileValues enables reading option values from a file by specifying -option=@filename on the commandline,
+// or from stdin with -option=@-. This keeps secrets like passwords out of shell history and process listings.
+// A value starting with a literal @ can be specified as @@.
+var AllowFileValues bool
+
+// MustNotConflict makes adding an option or command with the same name as an existing one panic, reporting the source
+// location of both. Set MustNotConflict to false before adding intentional overrides, the new definition will then
+// replace the existing one.
+var MustNotConflict = true
+
+var strictOptionsFile bool
+
+// SetStrictOptionsFile makes loading the options file fail if it contains keys that do not match any option,
+// reporting the names and positions of all of them, or if it contains Sensitive values and can be accessed by other
+// users than the owner. By default unknown keys are ignored and both are reported as warnings.
+func SetStrictOptionsFile(strict bool) {
+	strictOptionsFile = strict
+}
+
+var warningHandler = defaultWarningHandler
+
+func defaultWarningHandler(message string) {
+	fmt.Fprintln(os.Stderr, fmt.Sprintf(messages.Warning, message))
+}
+
+// SetWarningHandler sets the function called with non-fatal problems found while parsing, like unknown keys in the
+// options file. The default handler prints warnings to stderr, setting nil discards them.
+func SetWarningHandler(f func(message string)) {
+	warningHandler = f
+}
+
+// ShowCurrentValues adds hints to the help text of options that have been set from the options file or commandline,
+// showing the effective value instead of only the static default.
+var ShowCurrentValues bool
+
+var output io.Writer = os.Stdout       // Destination for help and other messages, replaced while ParseArgs is parsing
+var commandName string                 // Name of command to use in Usage instructions
+var commandList []*CmdCommand          // Internal list of all commands
+var optionList []*CmdOption            // Internal list of all options
+var nameNormalizer func(string) string // Applied to option names before matching
+
+var defaultOutput io.Writer = os.Stdout // Destination set with SetOutput, returned by Output
 
 func init() {
 	commandName = filepath.Base(os.Args[0])
@@ -43,165 +159,872 @@ func init() {
 
 /************************************* Core Functions  *************************************/
 
-// Usage will display the full commandline help message. This function is automatically called when the -h, -H or -? flag is specified.
+// SetOutput sets the destination for help, version and other messages printed by the parser. The default is os.Stdout.
+func SetOutput(w io.Writer) {
+	output, defaultOutput = w, w
+}
+
+// Output returns the destination for messages printed by the parser, as set with SetOutput. It is not replaced by
+// the writers of ServeCommands connections, commands added with CommandContext reach the client that sent the
+// command line through the Output of ResultFromContext.
+func Output() io.Writer {
+	return defaultOutput
+}
+
+// Reset removes all commands and options and restores the parser settings to their defaults, leaving only the
+// build metadata (Version, Commit and BuildDate) intact. Reset makes it possible to set up the parser more than once
+// in the same process, for example between tests or when plugins that added commands are reloaded. The hooks and
+// functions of the removed commands and options are cleared, so options that the application still refers to never
+// call back into code that has been replaced.
+func Reset() {
+	parseMutex.Lock()
+	defer parseMutex.Unlock()
+
+	for _, o := range optionList {
+		o.onChange, o.onChangeValue, o.onSave, o.onSaveE = nil, nil, nil, nil
+		o.saveTransform, o.loadTransform, o.defaultFunc, o.completer = nil, nil, nil, nil
+	}
+	for _, c := range commandList {
+		c.Function, c.onUnknownOption, c.run = nil, nil, nil
+	}
+	Args = nil
+	rawArgs = nil
+	Title = ""
+	OptionsFile = ""
+	PolicyFile = ""
+	AllowFileValues = false
+	AllowResponseFiles = false
+	MustNotConflict = true
+	ShowCurrentValues = false
+	RunProfilesFile = ""
+	OptionsVersion = 0
+	strictOptionsFile = false
+	warningHandler = defaultWarningHandler
+	output, defaultOutput = os.Stdout, os.Stdout
+	commandList = nil
+	optionList = nil
+	nameNormalizer = nil
+	invalidateIndex()
+	traceFunc = nil
+	usageRecorder = nil
+	features = make(map[string]*bool)
+	optionSort, commandSort = SortDeclared, SortDeclared
+	secretMutex.Lock()
+	secretAccesses = nil
+	secretMutex.Unlock()
+	clearWarnings()
+	interspersed = true
+	strictBool = false
+	caseInsensitiveCommands = false
+	normalizeDashes = false
+	permissionsWarned = make(map[string]bool)
+	helpFlags = []string{"-h", "-H", "-?"}
+	optionPrefixes, displayPrefix = []string{"-"}, "-"
+	argSource = ArgSourceFunc(func() []string { return os.Args })
+	optionsStore = defaultOptionsStore()
+	migrations = make(map[int]func(options map[string]interface{}) error)
+	commandDefaults = make(map[string]map[string]string)
+	replacedDefaults = make(map[*CmdOption]string)
+	derivedPending = make(map[*CmdOption]bool)
+	runTimeout = 0
+	compactErrors = false
+	localeNumbers = false
+	thousandsSeparators = false
+	optionsDirMode, optionsFileMode = 0700, 0700
+	requiredGroups = nil
+	nestedGroups = false
+	optionsProfiles, activeProfile = false, ""
+	updateChecker = nil
+	errorHandler = defaultErrorHandler
+	colorDisabled = false
+	WatchInterval = 2 * time.Second
+	derivedDeferred = false
+	messages = EnglishMessages
+
+	eventMutex.Lock()
+	subscribers = make(map[int]func(ev OptionEvent))
+	eventMutex.Unlock()
+}
+
+var helpFlags = []string{"-h", "-H", "-?"} // Tokens that display help
+
+// SetHelpFlags sets the tokens that display help, replacing the default -h, -H and -?. This makes it possible to use
+// one of them as a regular option, like -H for a header option. --help-full is available as long as there are help flags.
+//
+//	cmdparse.SetHelpFlags("-h", "--help")
+func SetHelpFlags(names ...string) {
+	helpFlags = names
+}
+
+// DisableBuiltinHelp turns off all help flags, including --help-full. Usage can still be called by the application.
+func DisableBuiltinHelp() {
+	helpFlags = nil
+}
+
+func isHelpFlag(arg string) bool {
+	if len(helpFlags) > 0 && arg == "--help-full" {
+		return true
+	}
+	for _, f := range helpFlags {
+		if arg == f {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage will display the full commandline help message. This function is automatically called when -h all or --help-full is specified,
+// while the -h, -H or -? flags display a summary without the options of each command. A help flag followed by a keyword,
+// like -h ignore, only shows the commands and options that mention the keyword. See SetHelpFlags for changing the flags.
 // Help text is automatically generated from available commands and options
 func Usage() {
+	usage(nil, true)
+}
+
+// usage displays the help message for focus and its child commands, or the help message of all commands if focus
+// is nil. Focused help includes the options that focus inherits from its parents.
+// Unless full is set, help of all commands is a summary without command options that uses ShortHelp when set.
+func usage(focus *CmdCommand, full bool) {
+	resolveDefaults()
+	colorOutput = useColor()
 	if Title != "" {
-		fmt.Printf(Title + "\n\n")
+		fmt.Fprintf(output, "%s\n\n", Title)
 	}
-	fmt.Println("Usage:")
-	for _, n := range commandList {
-		fmt.Printf("  %s [options] %s %s\n", commandName, n.Command, n.Help)
+	fmt.Fprintln(output, messages.Usage)
+	categories := []string{""} // Commands without a category are listed first
+	printCommands := func(category string) {
+		for _, n := range sortedCommands() {
+			if !n.available() {
+				continue
+			} else if (focus == nil || n.isWithin(focus)) && n.categoryName() == category {
+				n.printUsage(full)
+			} else if (focus == nil || n.isWithin(focus)) && !contains(categories, n.categoryName()) {
+				categories = append(categories, n.categoryName())
+			}
+		}
+	}
+	printCommands("")
+	if focus == nil && Version != "" && findCommand("version") == nil {
+		fmt.Fprintf(output, "  %s %s\n", commandName, colorize(colorBold, "version"))
+	}
+	if focus == nil && hasConfigCommand() {
+		fmt.Fprintf(output, "  %s %s %s\n", commandName, colorize(colorBold, configCommand), "get|set|unset|list [<name>] [<value>]")
+	}
+	for i := 1; i < len(categories); i++ {
+		fmt.Fprintln(output, "\n"+fmt.Sprintf(messages.CommandCategory, categories[i]))
+		printCommands(categories[i])
 	}
 
+	width := outputWidth()
+	printEntry := func(name string, text string) {
+		printHelpEntry(name, text, width)
+	}
 	printOption := func(n *CmdOption) {
-		fmt.Printf("  -%s", n.Name)
-		if n.Format != "" {
-			fmt.Printf("=%s", n.Format)
+		printEntry(optionHelp(n, full))
+	}
+	if focus != nil {
+		fmt.Fprintln(output)
+		for _, g := range sortedCommands() {
+			if g.isWithin(focus) {
+				g.printOptions(printOption)
+			}
+		}
+		for g := focus.parent; g != nil; g = g.parent {
+			g.printInheritedOptions(focus, printOption)
+		}
+		var examples []string
+		for _, g := range sortedCommands() {
+			if g.isWithin(focus) {
+				examples = append(examples, g.examples...)
+			}
+		}
+		if len(examples) > 0 {
+			fmt.Fprintln(output, "\n"+messages.Examples)
+			for _, e := range examples {
+				fmt.Fprintln(output, "  "+e)
+			}
+		}
+		return
+	}
+	fmt.Fprintln(output, "\n"+messages.Options)
+	for _, n := range sortedOptions() {
+		if n.Flags&Hidden == 0 && n.Group == "" {
+			printOption(n)
+		}
+	}
+	if OptionsFile != "" {
+		fmt.Fprintln(output)
+		printEntry(displayPrefix+"saveoptions[=<command>]", fmt.Sprintf(messages.SaveOptionsHelp, OptionsFile))
+		printEntry(displayPrefix+"except=<option>[,<option>]", fmt.Sprintf(messages.ExceptHelp, displayPrefix+"saveoptions"))
+		printEntry(displayPrefix+"showoptions[=file|effective|diff]", messages.ShowOptionsHelp)
+		printEntry(displayPrefix+"validateoptions", messages.ValidateOptionsHelp)
+		printEntry(displayPrefix+"editoptions", messages.EditOptionsHelp)
+		if optionsProfiles {
+			printEntry(displayPrefix+"profile=<name>", messages.ProfileHelp)
+			printEntry(displayPrefix+"profiles", messages.ProfilesHelp)
 		}
-		fmt.Printf("\n        %s", n.Help)
-		if n.Flags&Preference > 0 {
-			fmt.Printf(" (*)")
+	}
+	if RunProfilesFile != "" {
+		fmt.Fprintln(output)
+		printEntry(displayPrefix+"save-profile=<name>", messages.SaveProfileHelp)
+		printEntry(displayPrefix+"run-profile=<name>", messages.RunProfileHelp)
+	}
+	printEntry(displayPrefix+"showconfig", messages.ShowConfigHelp)
+	if hasVersion() {
+		printEntry(displayPrefix+"version[=json]", messages.VersionHelp)
+	}
+	if updateChecker != nil {
+		printEntry(displayPrefix+"check-update", messages.CheckUpdateHelp)
+	}
+	fmt.Fprintln(output)
+	for _, g := range sortedCommands() {
+		if g.Command == "" || !g.available() {
+			continue
+		} else if !full {
+			if g.hasVisibleOptions() && len(helpFlags) > 0 {
+				fmt.Fprintf(output, messages.HelpFullHint+"\n\n", helpFlags[0])
+				break
+			}
+		} else {
+			g.printOptions(printOption)
 		}
+	}
+
+}
+
+// printHelpEntry prints the name of an option or flag and its help text wrapped to width
+func printHelpEntry(name string, text string, width int) {
+	fmt.Fprintf(output, "  %s\n", colorize(colorBold, name))
+	fmt.Fprintln(output, strings.Replace(wrapText(text, helpIndent, width), messages.Required, colorize(colorRequired, messages.Required), -1))
+}
+
+// optionHelp returns the name and help text of an option as shown in help, including the long help if full is set
+func optionHelp(n *CmdOption, full bool) (string, string) {
+	name := displayPrefix + n.Name
+	if n.Format != "" {
+		name += "=" + n.Format
+	}
+	text := n.Help
+	if n.Flags&Required > 0 {
+		text += " " + messages.Required
+	}
+	if n.Flags&Preference > 0 {
+		text += " " + messages.Preference
+	}
+	if n.Flags&Locked > 0 {
+		text += " " + messages.Locked
+	}
+	if n.deprecated != nil {
+		text += " " + messages.Deprecated
+	}
+	if n.inherit {
+		text += " " + messages.Inherited
+	}
+	if n.defaultText != "" {
+		text += " " + fmt.Sprintf(messages.Default, n.defaultText)
+	} else if !n.hideDefault {
 		switch n.Value.(type) {
-		case *boolOption:
+		case *boolOption, boolPtrOption, flagBoolValue:
 			if n.Default == "true" {
-				fmt.Printf(" (default ON)")
+				text += " " + messages.DefaultOn
 			}
-		case *stringListOption:
+		case *stringListOption, *splitListOption, *intListOption, *floatListOption, *mapOption:
 			// Dont show it
 		default:
 			if n.Default != "" {
-				fmt.Printf(" (default %s)", n.Default)
+				text += " " + fmt.Sprintf(messages.Default, n.Default)
+			}
+		}
+	}
+	text += n.rangeText()
+	if n.example != "" {
+		text += " " + fmt.Sprintf(messages.ExampleText, n.example)
+	}
+	if len(n.aliases) > 0 {
+		var list []string
+		for _, a := range n.aliases {
+			list = append(list, a[0]+"="+a[1])
+		}
+		text += " " + fmt.Sprintf(messages.Aliases, strings.Join(list, ", "))
+	}
+	if ShowCurrentValues && n.source != SourceDefault {
+		switch n.Value.(type) {
+		case *byteOption, encodedByteOption:
+			text += " " + fmt.Sprintf(messages.CurrentlySet, n.source)
+		default:
+			value := n.Value.String()
+			if n.masked() {
+				value = maskedValue
 			}
+			text += " " + fmt.Sprintf(messages.Currently, value, n.source)
 		}
-		fmt.Print("\n")
 	}
-	fmt.Println("\nOptions:")
+	if full && n.longHelp != "" {
+		text += "\n" + n.longHelp
+	}
+	return name, text
+}
+
+// printUsage prints the usage line of the command, using ShortHelp when set unless full is set
+func (c *CmdCommand) printUsage(full bool) {
+	help := c.Help
+	if !full && c.ShortHelp != "" {
+		help = c.ShortHelp
+	}
+	if c.Command != "" && strings.HasPrefix(c.usage, c.Command) {
+		fmt.Fprintf(output, "  %s %s%s\n", commandName, colorize(colorBold, c.Command), c.usage[len(c.Command):])
+	} else if c.usage != "" {
+		fmt.Fprintf(output, "  %s %s\n", commandName, c.usage)
+	} else {
+		fmt.Fprintf(output, "  %s [options] %s %s\n", commandName, colorize(colorBold, c.Command), help)
+	}
+}
+
+func (c *CmdCommand) hasVisibleOptions() bool {
 	for _, n := range optionList {
-		if n.Flags&Hidden == 0 && n.Group == "" {
-			printOption(n)
+		if n.Flags&Hidden == 0 && n.Group == c.Command {
+			return true
 		}
 	}
-	if OptionsFile != "" {
-		fmt.Printf("\n  -saveoptions\n        Save (*) options to %s\n", OptionsFile)
-		fmt.Printf("  -showoptions\n        Show saved options\n")
+	return false
+}
+
+// printOptions prints the header and help of options in the command group, if there are any visible ones
+func (c *CmdCommand) printOptions(printOption func(n *CmdOption)) {
+	var printedHeader bool
+	for _, n := range sortedOptions() {
+		if n.Flags&Hidden == 0 && n.Group == c.Command {
+			if !printedHeader {
+				fmt.Fprintf(output, messages.CommandOptions+"\n", c.Command)
+				printedHeader = true
+			}
+			printOption(n)
+		}
 	}
-	if Title != "" {
-		fmt.Println("  -version\n        Show current version")
-	}
-	fmt.Println()
-	for _, g := range commandList {
-		if g.Command != "" {
-			var printedHeader bool
-			for _, n := range optionList {
-				if n.Flags&Hidden == 0 && n.Group == g.Command {
-					if !printedHeader {
-						fmt.Printf("%s options:\n", g.Command)
-						printedHeader = true
-					}
-					printOption(n)
-				}
+}
+
+// printInheritedOptions prints the header and help of the visible options that scope inherits from the command group,
+// leaving out options shadowed by an option of the same name closer to scope
+func (c *CmdCommand) printInheritedOptions(scope *CmdCommand, printOption func(n *CmdOption)) {
+	var printedHeader bool
+	for _, n := range sortedOptions() {
+		if n.Flags&Hidden == 0 && n.Group == c.Command && n.inherit && findScopedOption(n.Name, scope) == n {
+			if !printedHeader {
+				fmt.Fprintf(output, messages.InheritedOptions+"\n", c.Command)
+				printedHeader = true
 			}
+			printOption(n)
+		}
+	}
+}
+
+// ParseResult holds the outcome of a single call to ParseArgs or ParseWithResult
+type ParseResult struct {
+	Command     *CmdCommand             // Command that was resolved or nil if no command was found
+	Args        []string                // Arguments that were not parsed, not including the command name
+	RawArgs     []string                // Arguments following --, nil if there was no --
+	Sources     map[string]OptionSource // Where the value of each option came from, by option name as in the options file
+	Warnings    []Warning               // Non-fatal problems found while parsing
+	Occurrences []Occurrence            // Every option specified on the commandline in the order they were specified
+	Output      io.Writer               // Destination of messages and command output, the client for ServeCommands
+	Unknown     []string                // Unregistered options accepted by the OnUnknownOption handler, as specified
+
+	dispatch func(ctx context.Context) error // command function to call after parsing
+	known    []string                        // arguments that ParseKnown leaves for the caller
+}
+
+// Occurrence describes one use of an option on the commandline. Indexes refer to the commandline after response files
+// and run profiles have been expanded, with the program name at index 0.
+type Occurrence struct {
+	Option string   // Name of the option
+	Index  int      // Index of the first argument of the occurrence
+	Tokens []string // Arguments of the occurrence as specified, the option and its value if it was a separate argument
+	Value  string   // Value that was set, after reading @file values, blank if the option was specified without value
+}
+
+// OccurrencesOf returns the occurrences of the named option in the order they were specified
+func (r *ParseResult) OccurrencesOf(name string) []Occurrence {
+	var list []Occurrence
+	for _, o := range r.Occurrences {
+		if o.Option == name {
+			list = append(list, o)
 		}
 	}
+	return list
+}
+
+// Source returns where the value of the named option came from
+func (r *ParseResult) Source(name string) OptionSource {
+	return r.Sources[name]
+}
+
+// IsSet returns true if the named option was explicitly specified on the commandline
+func (r *ParseResult) IsSet(name string) bool {
+	return r.Sources[name] == SourceCommandLine
+}
+
+type resultKey struct{} // Context key of the ParseResult passed to commands added with CommandContext
 
+// ResultFromContext returns the ParseResult of the parse that dispatched a command added with CommandContext, or nil
+// if the context was not passed by the parser. Each call to ParseArgs has a result of its own, so the arguments in
+// its Args belong to the command line being run, unlike the global Args that is shared by all calls.
+//
+//	cmdparse.CommandContext("get", "<key>", func(ctx context.Context) {
+//	  args := cmdparse.ResultFromContext(ctx).Args
+//	})
+func ResultFromContext(ctx context.Context) *ParseResult {
+	r, _ := ctx.Value(resultKey{}).(*ParseResult)
+	return r
+}
+
+var parseMutex sync.Mutex    // Serializes parsing and changes to the registered commands and options
+var isolatedMutex sync.Mutex // Serializes ParseArgs calls with other parses, held while their command runs
+
+// ErrHelp is returned by the parse functions when help or version information was requested and displayed instead
+// of dispatching a command
+var ErrHelp = errors.New("Help requested")
+
+// ParseAndExit calls Parse and exits the program with conventional exit codes. It exits with 0 if help or version
+// information was displayed and with 2 after printing the error to stderr if the commandline could not be parsed.
+// ParseAndExit returns normally after the command function has returned.
+func ParseAndExit() {
+	if err := Parse(); err == ErrHelp {
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(2)
+	}
 }
 
 // Parse takes the full commandline and parse it according to options and commands that has been setup.
-// This is the core handler that will call underlying command functions
+// This is the core handler that will call underlying command functions.
+// Parse returns ErrHelp if help or version information was requested and displayed.
+// The hidden -explain flag prints the command, arguments and option values that would be used instead of calling
+// the command function, for support and debugging.
 func Parse() error {
+	_, err := ParseWithResult()
+	return err
+}
+
+// ParseWithResult works like Parse but also returns a ParseResult describing the resolved command and where each
+// option value came from, making it possible to tell if an option was actually specified on the commandline.
+func ParseWithResult() (*ParseResult, error) {
+	result, err := parseExclusive(argSource.Args(), parseCommandLine)
+
+	// The command runs unlocked as it can be a long running service using ParseArgs, ServeCommands or ReloadOptions
+	if err == nil && result.dispatch != nil {
+		err = result.dispatch(context.Background())
+	}
+	return result, err
+}
+
+// parseExclusive parses args without overlapping a ParseArgs call, whose option values are put back when its
+// command returns
+func parseExclusive(args []string, mode parseMode) (*ParseResult, error) {
+	isolatedMutex.Lock()
+	defer isolatedMutex.Unlock()
+	parseMutex.Lock()
+	defer parseMutex.Unlock()
+	return parse(args, mode)
+}
+
+// ParseArgs parses and dispatches the specified arguments (not including the command name) the same way Parse does
+// with the commandline. It is safe to call ParseArgs from several goroutines, for example to interpret command strings
+// arriving from several client connections, but calls are serialized and not concurrent: each call waits until the
+// previous call and its command have returned. Each call starts from the same option values, as the option variables
+// and the Args slice are changed for the duration of the call and put back before it returns. Values are put back
+// the same way as by Restore, calling OnChange hooks so that derived state follows. As the variables are shared,
+// other goroutines must not read option variables while ParseArgs runs, and a command run by ParseArgs must not call
+// ParseArgs or Parse itself. Use the returned ParseResult, or ResultFromContext in commands added with
+// CommandContext, to find out what was parsed for the call.
+func ParseArgs(args []string) (*ParseResult, error) {
+	return parseIsolated(args, nil, parseCommandLine)
+}
+
+// ParseLine splits a single line of input into arguments and parses and dispatches them like ParseArgs, so an
+// interactive shell can use the same commands and options as the commandline. Arguments are separated by whitespace
+// and can be quoted with ' or " or escaped with backslash. Each line starts from the same option values, as with
+// ParseArgs. An empty line is ignored and ErrHelp is returned if the line asked for help.
+//
+//	scanner := bufio.NewScanner(os.Stdin)
+//	for fmt.Print("> "); scanner.Scan(); fmt.Print("> ") {
+//	  if err := cmdparse.ParseLine(scanner.Text()); err != nil && err != cmdparse.ErrHelp {
+//	    fmt.Println(err)
+//	  }
+//	}
+func ParseLine(line string) error {
+	args, err := SplitCommandLine(line)
+	if err != nil || len(args) == 0 {
+		return err
+	}
+	_, err = ParseArgs(args)
+	return err
+}
+
+// parseIsolated parses and dispatches args and restores Args and option values afterwards. Messages printed while
+// parsing are sent to w, unless it is nil, which is also the Output of the result passed to the command. The command
+// runs without holding parseMutex, like with ParseWithResult, so that it can call ReloadOptions and the other
+// functions that take the lock.
+func parseIsolated(args []string, w io.Writer, mode parseMode) (*ParseResult, error) {
+	isolatedMutex.Lock()
+	defer isolatedMutex.Unlock()
+
+	parseMutex.Lock()
+	savedArgs, savedRawArgs := Args, rawArgs
+	savedValues := snapshotValues()
+	defer func() {
+		parseMutex.Lock()
+		Args, rawArgs = savedArgs, savedRawArgs
+		restoreValues(savedValues)
+		parseMutex.Unlock()
+	}()
+	result, err := func() (*ParseResult, error) {
+		defer parseMutex.Unlock()
+		if w != nil {
+			defer func(saved io.Writer) { output = saved }(output)
+			output = w
+		}
+		return parse(append([]string{commandName}, args...), mode)
+	}()
+
+	if err == nil && result.dispatch != nil {
+		err = result.dispatch(context.Background())
+	}
+	return result, err
+}
+
+// parseMode selects which features of the parser are available to a commandline
+type parseMode int
+
+const (
+	parseCommandLine parseMode = iota // Commandline of the process or ParseArgs, everything is available
+	parseRemote                       // Line received by ServeCommands, built-in flags and reading files are rejected
+	parseKnown                        // ParseKnown, other arguments are collected and commands are not dispatched
+)
+
+// parse is the core handler for Parse and ParseArgs, the first argument is the name of the command
+func parse(args []string, mode parseMode) (*ParseResult, error) {
+	result := &ParseResult{Output: output}
+	defer deferDerived()()
+	clearWarnings()
+	defer func() {
+		result.Warnings = Warnings()
+	}()
+	invalidateIndex() // Option names can have been changed since they were added
+	restoreDefaults()
+	resolveDefaults()
+	args = append(args[:1:1], normalizeArgs(args[1:])...)
+	// Built-in flags other than help read and write files of the program, which remote command lines must not do
+	builtin := func(arg string, name string) (string, bool) {
+		if mode != parseCommandLine {
+			return "", false
+		}
+		return builtinFlag(arg, name)
+	}
+	if len(args) > 1 && args[1] == metadataCommand && mode == parseCommandLine {
+		if err := printMetadata(); err != nil {
+			return result, err
+		}
+		return result, ErrHelp
+	}
+	if PolicyFile != "" {
+		if err := loadPolicy(PolicyFile); err != nil {
+			return result, err
+		}
+	}
 	if OptionsFile != "" {
+		var saving bool
+		activeProfile = ""
+		for _, a := range args[1:] {
+			if a == "--" {
+				break
+			} else if v, ok := builtin(a, "profile"); ok && optionsProfiles && v != "" {
+				activeProfile = v
+			} else if _, ok := builtin(a, "saveoptions"); ok {
+				saving = true
+			} else if v, ok := builtin(a, "validateoptions"); ok && v == "" {
+				return result, checkOptionsFile(OptionsFile)
+			} else if v, ok := builtin(a, "editoptions"); ok && v == "" {
+				return result, editOptions(OptionsFile)
+			}
+		}
+		if err := checkProfile(saving); err != nil {
+			return result, err
+		}
 		if err := loadOptions(OptionsFile); err != nil {
-			return err
+			return result, err
+		}
+	}
+	if len(args) > 1 && args[1] == completeCommand && mode == parseCommandLine {
+		complete(args[2:])
+		return result, nil
+	}
+
+	if AllowResponseFiles && mode == parseCommandLine {
+		var err error
+		if args, err = expandResponseFiles(args); err != nil {
+			return result, err
+		}
+	}
+	if RunProfilesFile != "" && mode == parseCommandLine {
+		var err error
+		if args, err = expandRunProfiles(args); err != nil {
+			return result, err
 		}
 	}
 
 	var stopParsing bool
+	var terminated = -1 // Number of arguments before --, which are the only ones that can be a command name
+	var raw []string    // Arguments following --
 	var doSave bool
+	var saveFor string // Command group to save options of, blank for all options
+	var saveExcept []string
 	var doShow bool
-	Args = nil
-	for i := 0; i < len(os.Args); i++ {
-		if !stopParsing && (os.Args[i] == "-?" || os.Args[i] == "-h" || os.Args[i] == "-H") {
-			Usage()
-			return nil
-		} else if !stopParsing && (os.Args[i] == "-version") {
-			if Title != "" {
-				fmt.Println(Title)
+	var showMode string
+	var doShowConfig bool
+	var doProfiles bool
+	var doExplain bool
+	var saveProfile string
+	var parsedArgs []string
+	var invocation []string  // Tokens to store when saving a run profile
+	var unknown [][]string   // Unknown options as name and optional value
+	var unknownArgs []string // Unknown options as specified
+	seen := make(map[*CmdOption]bool)
+	for i := 1; i < len(args); i++ {
+		start, record := i, true
+		if !stopParsing && mode != parseKnown && isHelpFlag(args[i]) {
+			// Help following a parent or child command is focused on that command
+			full := args[i] == "--help-full" || (i < len(args)-1 && args[i+1] == "all")
+			if keyword, ok := helpKeyword(args, i); ok {
+				searchHelp(keyword)
+			} else if c := matchCommand(parsedArgs); c != nil && c.Command != "" && (c.parent != nil || c.hasChildren() || len(c.examples) > 0) {
+				usage(c, true)
 			} else {
-				fmt.Println("No title has been set")
+				usage(nil, full)
+			}
+			return result, ErrHelp
+		} else if v, ok := builtin(args[i], "version"); !stopParsing && ok {
+			if err := printVersion(v); err != nil {
+				return result, err
+			}
+			return result, ErrHelp
+		} else if v, ok := builtin(args[i], "check-update"); !stopParsing && updateChecker != nil && ok && v == "" {
+			if err := checkUpdate(); err != nil {
+				return result, err
 			}
-			return nil
-		} else if !stopParsing && os.Args[i] == "--" {
+			return result, ErrHelp
+		} else if args[i] == "--" && mode == parseKnown {
+			result.known = append(result.known, args[i:]...)
+			break
+		} else if !stopParsing && args[i] == "--" {
 			stopParsing = true
-		} else if !stopParsing && OptionsFile != "" && os.Args[i] == "-saveoptions" {
-			doSave = true
-		} else if !stopParsing && OptionsFile != "" && os.Args[i] == "-showoptions" {
-			doShow = true
-		} else if !stopParsing && os.Args[i][0] == '-' {
-			pair := strings.Split(os.Args[i][1:], "=")
-			var option *CmdOption
-			for _, o := range optionList {
-				if o.Name == pair[0] {
-					option = o
-					break
+			terminated = len(parsedArgs)
+			raw = []string{}
+		} else if v, ok := builtin(args[i], "saveoptions"); !stopParsing && OptionsFile != "" && ok {
+			doSave, saveFor, record = true, v, false
+		} else if v, ok := builtin(args[i], "except"); !stopParsing && OptionsFile != "" && ok && v != "" {
+			saveExcept, record = append(saveExcept, strings.Split(v, ",")...), false
+		} else if v, ok := builtin(args[i], "showoptions"); !stopParsing && OptionsFile != "" && ok {
+			doShow, showMode, record = true, v, false
+		} else if v, ok := builtin(args[i], "profile"); !stopParsing && OptionsFile != "" && optionsProfiles && ok && v != "" {
+			record = false
+		} else if v, ok := builtin(args[i], "profiles"); !stopParsing && OptionsFile != "" && optionsProfiles && ok && v == "" {
+			doProfiles, record = true, false
+		} else if v, ok := builtin(args[i], "showconfig"); !stopParsing && ok && v == "" {
+			doShowConfig, record = true, false
+		} else if v, ok := builtin(args[i], "explain"); !stopParsing && ok && v == "" {
+			doExplain, record = true, false
+		} else if v, ok := builtin(args[i], "save-profile"); !stopParsing && RunProfilesFile != "" && ok && v != "" {
+			saveProfile, record = v, false
+		} else if name, ok := optionName(args[i]); !stopParsing && ok {
+			pair := strings.SplitN(name, "=", 2) // Only the first = separates the name, values can contain =
+			operator, pair := listOperator(pair, matchCommand(parsedArgs))
+			option := findScopedOption(pair[0], matchCommand(parsedArgs))
+			var ignore bool // Value is parsed but not set, for DuplicateFirst
+			if option != nil && !option.available() {
+				return result, fmt.Errorf(messages.OptionNotAvailable, displayPrefix+option.Name, runtime.GOOS)
+			}
+			if option != nil {
+				option.warnDeprecated()
+				if seen[option] && operator == 0 {
+					switch option.duplicates {
+					case DuplicateError:
+						return result, fmt.Errorf(messages.DuplicateOptionError, displayPrefix+option.Name)
+					case DuplicateFirst:
+						ignore = true
+					case DuplicateLast:
+						if option.accumulates() {
+							option.Value.Reset()
+						}
+					case DuplicateDefault:
+						if !option.accumulates() {
+							warn(WarningDuplicate, option.Name, messages.DuplicateOption, displayPrefix+option.Name)
+						}
+					}
 				}
+				seen[option] = true
 			}
-			if option == nil {
-				return errors.New("Invalid option -" + pair[0])
+			if option == nil && mode == parseKnown {
+				result.known = append(result.known, args[i])
+				continue
+			} else if option == nil {
+				// Unknown options are handed to the command if it accepts them, otherwise they are an error
+				unknown = append(unknown, pair)
+				unknownArgs = append(unknownArgs, args[i])
+				invocation = append(invocation, args[i])
+				continue
 			}
 
 			if len(pair) < 2 {
 				switch option.Value.(type) {
-				case *boolOption: // Special bool handling because a bool does not need a cmd line value
-					if i < len(os.Args)-1 && os.Args[i+1][0] != '-' {
-						if _, err := strconv.ParseBool(os.Args[i+1]); err != nil {
+				case *boolOption, boolPtrOption, disabledOption, flagBoolValue: // Special bool handling because a bool does not need a cmd line value
+					if !strictBool && i < len(args)-1 && isValueArg(option, args[i+1]) {
+						if _, err := strconv.ParseBool(args[i+1]); err != nil {
 							pair = append(pair, "true")
 						} else {
 							i++
-							pair = append(pair, os.Args[i])
+							pair = append(pair, args[i])
 						}
 					} else {
 						pair = append(pair, "true")
 					}
-				case *stringListOption:
-					if i < len(os.Args)-1 && os.Args[i+1][0] != '-' {
+				case *stringListOption, *splitListOption, *intListOption, *floatListOption, *mapOption:
+					if i < len(args)-1 && isValueArg(option, args[i+1]) {
 						i++
-						pair = append(pair, os.Args[i])
-					} else {
-						option.Value.(*stringListOption).FromString(option.Default)
+						pair = append(pair, args[i])
+					} else if !ignore {
+						option.setString(option.Default)
 					}
 				default:
-					if i < len(os.Args)-1 && os.Args[i+1][0] != '-' {
+					if i < len(args)-1 && isValueArg(option, args[i+1]) {
 						i++
-						pair = append(pair, os.Args[i])
+						pair = append(pair, args[i])
 					} else {
 						pair = append(pair, option.Default)
 					}
 				}
 			}
 
-			if len(pair) == 2 {
-				if pair[1] == "" {
-					option.Value.Reset()
-				} else {
-					if err := option.Value.Set(pair[1]); err != nil {
-						return errors.New(fmt.Sprintf("Invalid value set for option %s: \"%s\" (%s)", pair[0], pair[1], err.Error()))
+			if len(pair) == 2 && !ignore {
+				if mode != parseRemote {
+					v, err := option.commandLineValue(pair[1])
+					if err != nil {
+						return result, fmt.Errorf(messages.ValueFileError, pair[0], err.Error())
 					}
+					pair[1] = v
+				}
+				var err error
+				switch operator {
+				case listRemove:
+					err = option.remove(pair[1])
+				case listReplace:
+					if option.Flags&Locked > 0 {
+						err = option.lockedError()
+						break
+					}
+					option.Value.Reset()
+					fallthrough
+				default:
+					err = option.apply(pair[1], SourceCommandLine)
+				}
+				if err != nil {
+					return result, option.invalidValue(pair[0], pair[1], err)
 				}
-				option.doChange()
+				option.traceSet(start)
+			}
+			occurrence := Occurrence{Option: option.Name, Index: start, Tokens: append([]string{}, args[start:i+1]...)}
+			if len(pair) == 2 {
+				occurrence.Value = pair[1]
+			}
+			result.Occurrences = append(result.Occurrences, occurrence)
+			if option.Flags&Sensitive > 0 {
+				record = false
 			}
 		} else {
-			Args = append(Args, os.Args[i])
+			if mode == parseKnown {
+				result.known = append(result.known, args[i])
+			}
+			parsedArgs = append(parsedArgs, args[i])
+			if raw != nil {
+				raw = append(raw, args[i])
+			}
+			stopParsing = stopParsing || !interspersed
+			if c := matchCommand(parsedArgs); !stopParsing && c != nil && c.remainder != nil &&
+				len(parsedArgs)-len(strings.Fields(c.Command)) == c.remainderAfter {
+				// Everything following is the remainder of the command, as if it followed --
+				stopParsing = true
+				terminated = len(parsedArgs)
+			}
+			trace(ParseEvent{Type: TraceArgSkipped, Value: args[i], Index: i})
 		}
+		if record {
+			invocation = append(invocation, args[start:i+1]...)
+		}
+	}
+	if mode == parseKnown {
+		return result, nil
 	}
 
-	if doShow {
-		js, err := jsonOptions()
-		if err != nil {
-			return err
+	if terminated < 0 {
+		terminated = len(parsedArgs)
+	}
+	command := matchCommand(parsedArgs[:terminated])
+	if command != nil && !command.available() {
+		return result, fmt.Errorf(messages.CommandNotAvailable, command.Command, runtime.GOOS)
+	}
+	if command != nil {
+		command.args = parsedArgs[len(strings.Fields(command.Command)):]
+		if command.remainder != nil {
+			*command.remainder = []string{}
+			if len(command.args) > command.remainderAfter {
+				*command.remainder = append(*command.remainder, command.args[command.remainderAfter:]...)
+			}
+		}
+		trace(ParseEvent{Type: TraceCommandMatched, Name: command.Command})
+		if err := applyCommandDefaults(command); err != nil {
+			return result, err
+		}
+	}
+	if err := applyInheritedValues(); err != nil {
+		return result, err
+	}
+	Args = parsedArgs
+	rawArgs = raw
+	if command != nil {
+		Args = command.args
+	}
+	result.Command = command
+	for i, u := range unknown {
+		if command == nil || command.onUnknownOption == nil {
+			return result, fmt.Errorf(messages.InvalidOption, displayPrefix+u[0])
+		}
+		value := strings.Join(u[1:], "=")
+		if err := command.onUnknownOption(u[0], value); err != nil {
+			return result, err
+		}
+		result.Unknown = append(result.Unknown, unknownArgs[i])
+	}
+	result.Args = append([]string{}, Args...)
+	result.RawArgs = raw
+	result.Sources = make(map[string]OptionSource)
+	for _, o := range optionList {
+		result.Sources[o.key()] = o.source
+	}
+	if saveProfile != "" {
+		if err := saveRunProfile(saveProfile, invocation); err != nil {
+			return result, err
+		}
+		fmt.Fprintf(output, messages.ProfileSaved+"\n", saveProfile, RunProfilesFile)
+	} else if doProfiles {
+		if err := listProfiles(); err != nil {
+			return result, err
+		}
+	} else if doShowConfig {
+		showConfig()
+	} else if doShow {
+		if err := showOptions(showMode); err != nil {
+			return result, err
 		}
-		fmt.Println(string(js))
 	} else {
 		/*for _, n := range optionList {
 			if (*n).Function != nil {
@@ -209,199 +1032,1818 @@ func Parse() error {
 			}
 		}*/
 
+		if len(saveExcept) > 0 && !doSave {
+			return result, fmt.Errorf(messages.ExceptWithoutSave, displayPrefix+"except", displayPrefix+"saveoptions")
+		}
 		if doSave {
-			_, err := saveOptions(OptionsFile)
+			restore, err := excludeOptions(saveExcept)
 			if err != nil {
-				return err
+				return result, err
+			}
+			if saveFor != "" {
+				_, err = saveOptionsFor(OptionsFile, saveFor)
+			} else {
+				_, err = saveOptions(OptionsFile)
+			}
+			restore()
+			if err != nil {
+				return result, err
 			}
-			fmt.Println("Options saved to " + OptionsFile)
+			fmt.Fprintf(output, messages.OptionsSaved+"\n", OptionsFile)
 		} else {
-			var command *CmdCommand
-			for _, c := range commandList {
-				if (len(Args) > 1 && c.Command == Args[1]) || c.Command == "" {
-					command = c
-					if c.Command != "" {
-						break
-					}
-				}
+			if Version != "" && len(parsedArgs) > 0 && commandWord(parsedArgs[0], "version") && findCommand("version") == nil && mode == parseCommandLine {
+				return result, printVersion("")
+			}
+			if len(parsedArgs) > 0 && commandWord(parsedArgs[0], configCommand) && hasConfigCommand() && mode == parseCommandLine {
+				return result, runConfig(parsedArgs[1:])
 			}
 			if command != nil {
+				var missing []string
+				for c := command; c != nil; c = c.parent {
+					for _, name := range c.requiredOptions {
+						if findScopedOption(name, command) == nil {
+							return result, fmt.Errorf(messages.UnknownRequiredOption, c.Command, displayPrefix+name)
+						}
+					}
+				}
 				for _, n := range optionList {
-					if n.Flags&Required > 0 && n.Value.String() == n.Default {
-						return errors.New("Missing required option -" + n.Name)
+					if (n.Flags&Required > 0 || command.requires(n)) && !n.changed() && n.available() {
+						if n.Flags&Hidden > 0 {
+							missing = append(missing, displayPrefix+n.Name+" "+messages.Hidden)
+						} else {
+							missing = append(missing, displayPrefix+n.Name)
+						}
+					}
+				}
+				if len(missing) > 0 && compactErrors {
+					compactUsage(command, nil)
+				}
+				if len(missing) == 1 {
+					return result, fmt.Errorf(messages.MissingRequiredOption, missing[0])
+				} else if len(missing) > 1 {
+					return result, fmt.Errorf(messages.MissingRequiredOptions, strings.Join(missing, ", "))
+				}
+				if err := checkGroups(command); err != nil {
+					if compactErrors {
+						compactUsage(command, nil)
 					}
+					return result, err
+				}
+
+				if command.runnable() && command.elevation && !isElevated() && !doExplain {
+					return result, &ElevationError{Command: command.Command}
 				}
+				if command.runnable() && doExplain {
+					explain(command)
+				} else if command.runnable() {
+					if err := updateRecent(); err != nil {
+						warn(WarningRecent, "", messages.RecentNotSaved, err.Error())
+					}
+					function, run := command.Function, command.run
+					result.dispatch = func(ctx context.Context) error {
+						var err error
+						if run != nil {
+							err = run(context.WithValue(ctx, resultKey{}, result))
+						} else {
+							function()
+						}
+						result.recordUsage()
+						return err
+					}
+				} else if command.hasChildren() {
+					if compactErrors {
+						compactUsage(command, nil)
+					} else {
+						usage(command, true)
+					}
+					return result, fmt.Errorf(messages.MissingSubCommand, command.Command)
+				}
+
+			} else {
+				if len(parsedArgs) == 0 {
+					if compactErrors {
+						compactUsage(nil, nil)
+					} else {
+						Usage()
+					}
+					return result, errors.New(messages.MissingCommand)
+				} else {
+					if compactErrors {
+						compactUsage(nil, parsedArgs)
+					}
+					return result, fmt.Errorf(messages.InvalidCommand, parsedArgs[0])
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// showConfig prints the effective value of every option together with where the value was set from, the values of
+// Masked and Sensitive options are masked
+func showConfig() {
+	var width int
+	for _, o := range optionList {
+		if len(displayPrefix+o.Name) > width {
+			width = len(displayPrefix + o.Name)
+		}
+	}
+	for _, o := range optionList {
+		scope := ""
+		if o.Group != "" {
+			scope = fmt.Sprintf(messages.CommandOption, o.Group)
+		}
+		value := o.Value.String()
+		if value == "" {
+			value = `""`
+		} else if o.masked() {
+			value = maskedValue
+		}
+		fmt.Fprintf(output, "%-*s = %s (%s%s)\n", width, displayPrefix+o.Name, value, o.source, scope)
+	}
+}
+
+// explain prints what would run instead of running the command, for the hidden -explain flag. This shows how the
+// commandline was understood, with Masked and Sensitive option values masked to make the output safe to share.
+func explain(command *CmdCommand) {
+	name := command.Command
+	if name == "" {
+		name = messages.DefaultCommand
+	}
+	fmt.Fprintf(output, messages.ExplainCommand+"\n", name)
+	fmt.Fprintf(output, messages.ExplainArguments+"\n", command.args)
+	if rawArgs != nil {
+		fmt.Fprintf(output, messages.ExplainRawArguments+"\n", rawArgs)
+	}
+	fmt.Fprintln(output, messages.Options)
+	showConfig()
+}
+
+// isValueArg returns true if the argument following an option without value can be used as its value.
+// Arguments starting with - are treated as the next option, except negative numbers for numeric options and a
+// single - that commonly means stdin. Any value can be specified with -name=value.
+func isValueArg(option *CmdOption, arg string) bool {
+	if _, ok := optionName(arg); !ok {
+		return true
+	}
+	numeric := false
+	switch v := option.Value.(type) {
+	case *intOption, *floatOption, *intListOption, *floatListOption:
+		numeric = true
+	case interface{ numeric() bool }:
+		numeric = v.numeric()
+	}
+	if numeric {
+		if _, err := strconv.ParseInt(arg, 0, 64); err == nil {
+			return true
+		}
+		_, err := strconv.ParseFloat(arg, 64)
+		return err == nil
+	}
+	return false
+}
+
+// commandLineValue returns the value of the option as specified on the commandline, with @filename values read
+// when AllowFileValues is set and the file:// prefix of byte options replaced by the encoded content of the file.
+// Files are only read for the commandline, not for values from the options file, environment or remote clients.
+func (c *CmdOption) commandLineValue(value string) (string, error) {
+	if AllowFileValues {
+		v, err := readValueFile(value)
+		if err != nil {
+			return "", err
+		}
+		value = v
+	}
+	if b, ok := c.Value.(interface{ encode([]byte) string }); ok && strings.HasPrefix(value, byteFilePrefix) {
+		data, err := ioutil.ReadFile(value[len(byteFilePrefix):])
+		if err != nil {
+			return "", err
+		}
+		value = b.encode(data)
+	}
+	return value, nil
+}
+
+// readValueFile returns the content of the file (or stdin) referenced by an @filename value, without trailing newline.
+// Values not starting with @ are returned as is.
+func readValueFile(value string) (string, error) {
+	if !strings.HasPrefix(value, "@") {
+		return value, nil
+	} else if strings.HasPrefix(value, "@@") {
+		return value[1:], nil
+	}
+
+	var data []byte
+	var err error
+	if value == "@-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(value[1:])
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+/************************************* Preferences Functions  *************************************/
+
+// checkOptionsFile validates the options file and prints every problem found. An error is returned if
+// the file could not be read or contains problems, making it suitable for validating configs in CI.
+func checkOptionsFile(name string) error {
+	problems, err := validateOptions(name)
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fmt.Fprintln(output, p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf(messages.ProblemsFound, len(problems), name)
+	}
+	fmt.Fprintf(output, messages.NoProblems+"\n", name)
+	return nil
+}
+
+// jsonValue returns the value of the option as it is saved in the options file
+func (c *CmdOption) jsonValue(mask bool) interface{} {
+	if mask && c.masked() {
+		return maskedValue
+	} else if c.Flags&Sensitive > 0 {
+		recordAccess(c.Name)
+	}
+	if m, ok := c.Value.(json.Marshaler); ok {
+		return m
+	} else if j, ok := c.Value.(interface {
+		jsonValue() interface{}
+	}); ok {
+		return j.jsonValue()
+	}
+	return c.Value.Get()
+}
+
+// masked returns true if the value of the option is shown as ******** in help, -showconfig, -showoptions and traces
+func (c *CmdOption) masked() bool {
+	return c.Flags&(Masked|Sensitive) > 0
+}
+
+// savedValue returns the value of the option as it is saved in the options file, after the SaveTransform
+func (c *CmdOption) savedValue(mask bool) (interface{}, error) {
+	value := c.jsonValue(mask)
+	if c.saveTransform == nil || (mask && c.masked()) {
+		return value, nil
+	}
+	value, err := c.saveTransform(value)
+	if err != nil {
+		return nil, fmt.Errorf(messages.SaveOptionFailed, c.Name, err.Error())
+	}
+	return value, nil
+}
+
+// loadedValue returns a value read from the options file as it is set to the option, after the LoadTransform
+func (c *CmdOption) loadedValue(v interface{}) (interface{}, error) {
+	if c.loadTransform == nil {
+		return v, nil
+	}
+	return c.loadTransform(v)
+}
+
+// noPersistOptions returns the values of NoPersist options in the current options file, so that saving keeps them
+func noPersistOptions(mask bool) (map[string]interface{}, error) {
+	optionMap := make(map[string]interface{})
+	var keep bool
+	for _, o := range optionList {
+		keep = keep || (o.noPersist && o.Flags&Preference > 0)
+	}
+	if !keep {
+		return optionMap, nil
+	}
+	data, err := optionsStore.Load(OptionsFile)
+	if os.IsNotExist(err) {
+		return optionMap, nil
+	} else if err != nil {
+		return nil, err
+	}
+	entries, offset, err := decodeOptions(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filePosition(OptionsFile, data, offset), err.Error())
+	}
+	for _, e := range entries {
+		if o := findRegisteredOption(e.key); o != nil && o.noPersist && o.Flags&Preference > 0 {
+			if mask && o.masked() {
+				optionMap[e.key] = maskedValue
+			} else {
+				optionMap[e.key] = e.value
+			}
+		}
+	}
+	return optionMap, nil
+}
+
+// showOptions prints the options that would be saved, or with a mode the options file, the effective value of
+// all preference options or the differences between them
+func showOptions(mode string) error {
+	var fileMap map[string]interface{}
+	if mode == "file" || mode == "diff" {
+		fileMap = make(map[string]interface{})
+		data, err := optionsStore.Load(OptionsFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		} else if err == nil {
+			entries, offset, err := decodeOptions(data)
+			if err != nil {
+				return fmt.Errorf("%s: %s", filePosition(OptionsFile, data, offset), err.Error())
+			}
+			for _, e := range entries {
+				fileMap[e.key] = e.value
+			}
+			if err := migrateOptions(fileMap); err != nil {
+				return fmt.Errorf("%s: %s", OptionsFile, err.Error())
+			}
+		}
+	}
+
+	var js []byte
+	var err error
+	switch mode {
+	case "":
+		js, err = jsonOptions(true)
+	case "file":
+		for _, o := range optionList {
+			if _, ok := fileMap[o.key()]; ok && o.masked() {
+				fileMap[o.key()] = maskedValue
+			}
+		}
+		js, err = json.MarshalIndent(fileMap, "", "\t")
+	case "effective":
+		effective := make(map[string]interface{})
+		for _, o := range optionList {
+			if o.Flags&Preference > 0 {
+				effective[o.key()] = o.jsonValue(true)
+			}
+		}
+		js, err = json.MarshalIndent(effective, "", "\t")
+	case "diff":
+		var differences int
+		for _, o := range optionList {
+			if o.Flags&Preference == 0 {
+				continue
+			}
+			saved, inFile := fileMap[o.key()]
+			if !inFile && !o.changed() {
+				continue
+			}
+			fileText, _ := json.Marshal(saved)
+			value, err := o.savedValue(false)
+			if err != nil {
+				return err
+			}
+			effectiveText, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			if inFile && bytes.Equal(fileText, effectiveText) {
+				continue
+			}
+			if !inFile {
+				fileText = []byte(messages.NotSaved)
+			} else if o.masked() {
+				fileText = []byte(maskedValue)
+			}
+			if o.masked() {
+				effectiveText = []byte(maskedValue)
+			}
+			fmt.Fprintf(output, "%s: %s -> %s (%s)\n", displayPrefix+o.key(), fileText, effectiveText, o.source)
+			differences++
+		}
+		if differences == 0 {
+			fmt.Fprintf(output, messages.NoDifferences+"\n", OptionsFile)
+		}
+		return nil
+	default:
+		return fmt.Errorf(messages.InvalidShowOptionsMode, displayPrefix+"showoptions", mode)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(output, string(js))
+	return nil
+}
+
+func jsonOptions(mask bool) ([]byte, error) {
+	optionMap, err := noPersistOptions(mask)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range optionList {
+		if v.persisted() {
+			if err := v.doSave(); err != nil {
+				return nil, err
+			}
+			value, err := v.savedValue(mask)
+			if err != nil {
+				return nil, err
+			}
+			optionMap[v.key()] = value
+		}
+	}
+
+	if recent := recentValues(); len(recent) > 0 {
+		optionMap[recentKey] = recent
+	}
+	if OptionsFile != "" && !mask {
+		// Profiles are kept as they are when saving the options at the top of the file
+		profiles, err := storedProfiles()
+		if err != nil {
+			return nil, err
+		} else if len(profiles) > 0 {
+			optionMap[profilesKey] = profiles
+		}
+	}
+	if OptionsVersion > 0 {
+		optionMap[optionsVersionKey] = OptionsVersion
+	}
+	nestOptions(optionMap)
+	jsonData, err := json.MarshalIndent(optionMap, "", "\t")
+	if err != nil {
+		return nil, err
+	}
+
+	return jsonData, nil
+}
+
+func saveOptions(name string) (string, error) {
+	if activeProfile != "" {
+		return saveProfileOptions(name, "")
+	}
+	jsonData, err := jsonOptions(false)
+	if err != nil {
+		return "", err
+	}
+
+	if err := optionsStore.Save(name, jsonData, optionsFilePerm()); err != nil {
+		return "", err
+	}
+	for _, o := range optionList {
+		if o.persisted() {
+			emitEvent(OptionSaved, o)
+		}
+	}
+	return string(jsonData), nil
+}
+
+// SaveOptionsFor saves the Preference options of a command group to a section of the options file named after the
+// command, leaving the rest of the file as it is. Options in a section are loaded like any other saved options.
+// This is the same as specifying -saveoptions=<command> on the commandline.
+//
+//	{
+//	  "server": "localhost",
+//	  "push": {
+//	    "retries": 3
+//	  }
+//	}
+func SaveOptionsFor(cmd string) error {
+	if OptionsFile == "" {
+		return errors.New(messages.NoOptionsFile)
+	}
+	_, err := saveOptionsFor(OptionsFile, cmd)
+	return err
+}
+
+// saveOptionsFor replaces the section of the command in the options file with its current options
+func saveOptionsFor(name string, cmd string) (string, error) {
+	if activeProfile != "" {
+		return saveProfileOptions(name, cmd)
+	}
+	if findCommand(cmd) == nil {
+		return "", fmt.Errorf(messages.InvalidCommand, cmd)
+	}
+	optionMap := make(map[string]interface{})
+	section := make(map[string]interface{})
+	data, err := optionsStore.Load(name)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	} else if err == nil {
+		entries, offset, err := decodeEntries(data)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", filePosition(name, data, offset), err.Error())
+		}
+		for _, e := range entries {
+			if e.key == cmd && findRegisteredOption(e.key) == nil {
+				// Values of NoPersist options are kept in the new section
+				if old, ok := e.value.(map[string]interface{}); ok {
+					for k, v := range old {
+						if o := findRegisteredOption(sectionKey(cmd, k)); o != nil && o.noPersist {
+							section[k] = v
+						}
+					}
+				}
+				continue
+			}
+			if o := findRegisteredOption(e.key); o != nil && o.Group == cmd && !o.noPersist {
+				continue
+			}
+			optionMap[e.key] = e.value
+		}
+	}
+
+	for _, o := range optionList {
+		if o.Group == cmd && o.persisted() {
+			if err := o.doSave(); err != nil {
+				return "", err
+			}
+			value, err := o.savedValue(false)
+			if err != nil {
+				return "", err
+			}
+			section[o.Name] = value
+		}
+	}
+	if len(section) > 0 {
+		optionMap[cmd] = section
+	}
+	if OptionsVersion > 0 {
+		optionMap[optionsVersionKey] = OptionsVersion
+	}
+	jsonData, err := json.MarshalIndent(optionMap, "", "\t")
+	if err != nil {
+		return "", err
+	}
+	if err := optionsStore.Save(name, jsonData, optionsFilePerm()); err != nil {
+		return "", err
+	}
+	for _, o := range optionList {
+		if o.Group == cmd && o.persisted() {
+			emitEvent(OptionSaved, o)
+		}
+	}
+	return string(jsonData), nil
+}
+
+// writeFileAtomic writes data to a temporary file in the same folder and renames it over the destination, so that
+// the destination is never left partially written. An existing destination keeps its permissions and owner.
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	existing, statErr := os.Stat(name)
+	if statErr == nil {
+		perm = existing.Mode().Perm()
+	}
+	file, err := ioutil.TempFile(filepath.Dir(name), filepath.Base(name)+".tmp")
+	if err != nil {
+		return err
+	}
+	tempName := file.Name()
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tempName, perm)
+	}
+	if err == nil && statErr == nil {
+		keepOwner(tempName, existing)
+	}
+	if err == nil {
+		err = os.Rename(tempName, name)
+	}
+	if err != nil {
+		os.Remove(tempName)
+	}
+	return err
+}
+
+func loadOptions(name string) error {
+	data, err := optionsStore.Load(name)
+	if err != nil {
+		return nil
+	}
+
+	entries, offset, err := decodeOptions(data)
+	if err != nil {
+		return fmt.Errorf("%s: %s", filePosition(name, data, offset), err.Error())
+	}
+	normalizeEntries(entries, func(e optionsEntry, key string) {
+		warn(WarningNormalizedKey, key, "%s: "+messages.NormalizedKey, filePosition(name, data, e.offset), e.key, key)
+	})
+	optionMap := make(map[string]interface{})
+	offsets := make(map[string]int64)
+	for _, e := range entries {
+		optionMap[e.key] = e.value
+		offsets[e.key] = e.offset
+	}
+	if err := migrateOptions(optionMap); err != nil {
+		return fmt.Errorf("%s: %s", name, err.Error())
+	}
+	loadRecent(optionMap[recentKey])
+	delete(optionMap, recentKey)
+
+	var unknown []string
+	for _, e := range entries {
+		if _, ok := optionMap[e.key]; ok && findRegisteredOption(e.key) == nil {
+			unknown = append(unknown, fmt.Sprintf("%s: "+messages.FileUnknownOption, filePosition(name, data, e.offset), e.key))
+			if !strictOptionsFile {
+				warn(WarningUnknownOption, e.key, messages.UnknownOptionIgnored, unknown[len(unknown)-1])
+			}
+		}
+	}
+	if len(unknown) > 0 && strictOptionsFile {
+		return errors.New(strings.Join(unknown, "\n"))
+	}
+	if err := checkPermissions(name, optionMap); err != nil {
+		return err
+	}
+
+	for _, o := range optionList {
+		if v, ok := optionMap[o.key()]; ok {
+			previous := o.plainString()
+			v, err := o.loadedValue(v)
+			if err == nil {
+				err = setJSONValue(o, v)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: "+messages.FileInvalidValue, filePosition(name, data, offsets[o.key()]), o.key(), err.Error(), o.expectation())
+			}
+			if o.Flags&Locked > 0 {
+				if o.plainString() != previous {
+					o.setString(previous)
+					return fmt.Errorf("%s: %s", filePosition(name, data, offsets[o.key()]), o.lockedError().Error())
+				}
+				continue
+			}
+			o.source = SourceOptionsFile
+			o.warnDeprecated()
+			emitEvent(OptionLoaded, o)
+			o.traceSet(0)
+			if v != nil {
+				o.doChange()
+			}
+		}
+	}
+	return nil
+}
+
+// optionsEntry is a key and value read from the options file together with the offset of the key
+type optionsEntry struct {
+	key    string
+	value  interface{}
+	offset int64
+}
+
+// decodeOptions decodes the JSON object of an options file into a list of entries in file order, with the options
+// in command sections as separate entries and the options of the selected profile last. If decoding fails the error
+// is returned together with the offset where it occurred.
+func decodeOptions(data []byte) ([]optionsEntry, int64, error) {
+	entries, offset, err := decodeEntries(data)
+	if err != nil {
+		return nil, offset, err
+	}
+	var expanded, profile []optionsEntry
+	for _, e := range entries {
+		if e.key == profilesKey {
+			profile = profileEntries(e) // Options of the selected profile are applied last
+			continue
+		}
+		expanded = append(expanded, expandEntry(e)...)
+	}
+	return append(expanded, profile...), 0, nil
+}
+
+// expandEntry returns the options in a command section of the options file as separate entries, or the entry itself
+// if it is not a command section
+func expandEntry(e optionsEntry) []optionsEntry {
+	section, ok := e.value.(map[string]interface{})
+	if !ok || findRegisteredOption(e.key) != nil || findCommand(e.key) == nil {
+		return []optionsEntry{e}
+	}
+	var names []string
+	for name := range section {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var expanded []optionsEntry
+	for _, name := range names {
+		expanded = append(expanded, optionsEntry{key: sectionKey(e.key, name), value: section[name], offset: e.offset})
+	}
+	return expanded
+}
+
+// sectionKey returns the key of an option in a command section of the options file
+func sectionKey(cmd string, name string) string {
+	for _, o := range lookupIndex().byName[normalizeName(name)] {
+		if o.Group == cmd && o.Name == name {
+			return o.key()
+		}
+	}
+	return cmd + "." + name
+}
+
+// decodeEntries decodes the JSON object of an options file into a list of entries in file order
+func decodeEntries(data []byte) ([]optionsEntry, int64, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if t, err := dec.Token(); err != nil {
+		return nil, jsonErrorOffset(err, dec), err
+	} else if d, ok := t.(json.Delim); !ok || d != '{' {
+		return nil, 0, errors.New(messages.FileNotObject)
+	}
+
+	var entries []optionsEntry
+	for dec.More() {
+		t, err := dec.Token()
+		if err != nil {
+			return nil, jsonErrorOffset(err, dec), err
+		}
+		e := optionsEntry{key: t.(string)}
+		e.offset = dec.InputOffset() - int64(len(e.key)) - 2
+		if err := dec.Decode(&e.value); err != nil {
+			return nil, jsonErrorOffset(err, dec), err
+		}
+		entries = append(entries, e)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, jsonErrorOffset(err, dec), err
+	}
+	return entries, 0, nil
+}
+
+// setJSONValue sets an option from a value decoded from the options file
+func setJSONValue(o *CmdOption, v interface{}) error {
+	if u, ok := o.Value.(json.Unmarshaler); ok && v != nil {
+		// Custom values decode their own JSON, re-encoding the generic value keeps loading in a single pass
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		o.Value.Reset()
+		return u.UnmarshalJSON(data)
+	}
+	switch t := v.(type) {
+	case nil: // for JSON null
+		o.Value.Reset()
+	case map[string]interface{}: // for JSON objects
+		m, ok := o.Value.(*mapOption)
+		if !ok {
+			return errors.New(messages.ObjectNotSupported)
+		}
+		m.Reset()
+		for k, e := range t {
+			s, ok := e.(string)
+			if !ok {
+				return fmt.Errorf(messages.ObjectValueNotString, e, k)
+			}
+			if err := o.set(k + "=" + s); err != nil {
+				return err
+			}
+		}
+	case []interface{}: // for JSON arrays
+		o.Value.Reset()
+		for _, e := range t {
+			s, ok := e.(string)
+			switch o.Value.(type) {
+			case *intListOption, *floatListOption:
+				if f, isNumber := e.(float64); isNumber {
+					s, ok = strconv.FormatFloat(f, 'f', -1, 64), true
+				}
+			}
+			if !ok {
+				return fmt.Errorf(messages.ArrayValueNotString, e)
+			}
+			if l, ok := o.Value.(*splitListOption); ok {
+				*l = append(*l, s) // saved values are already split
+			} else if err := o.set(s); err != nil {
+				return err
+			}
+		}
+	case float64: // for JSON numbers, %v would format large numbers with an exponent
+		if err := o.set(strconv.FormatFloat(t, 'f', -1, 64)); err != nil {
+			return err
+		}
+	default:
+		if err := o.set(fmt.Sprintf("%v", t)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateOptions checks the options file against the registered options and returns a list of
+// problems found, each prefixed with the file name, line and column of the offending key
+func validateOptions(name string) ([]string, error) {
+	data, err := optionsStore.Load(name)
+	if err != nil {
+		return nil, err
+	}
+	return validateOptionsData(name, data), nil
+}
+
+// validateOptionsData checks options file data against the registered options, name is used for file positions
+func validateOptionsData(name string, data []byte) []string {
+	var problems []string
+	report := func(offset int64, format string, a ...interface{}) {
+		problems = append(problems, fmt.Sprintf("%s: %s", filePosition(name, data, offset), fmt.Sprintf(format, a...)))
+	}
+
+	entries, offset, err := decodeOptions(data)
+	if err != nil {
+		report(offset, "%v", err)
+		return problems
+	}
+	normalizeEntries(entries, func(optionsEntry, string) {})
+
+	// Validate the options as they will be after migration, in file order followed by keys added by migrations
+	optionMap := make(map[string]interface{})
+	for _, e := range entries {
+		optionMap[e.key] = e.value
+		if e.key != optionsVersionKey {
+			continue
+		}
+		if v, ok := e.value.(float64); !ok || v != float64(int(v)) {
+			report(e.offset, messages.FileInvalidVersion, e.value)
+			return problems
+		} else if int(v) > OptionsVersion {
+			report(e.offset, messages.FileNewerVersion, int(v), OptionsVersion)
+		}
+	}
+	if err := migrateOptions(optionMap); err != nil {
+		report(0, "%v", err)
+		return problems
+	}
+	delete(optionMap, recentKey)
+	var added []string
+	for key := range optionMap {
+		added = append(added, key)
+	}
+	sort.Strings(added)
+	for _, key := range added {
+		entries = append(entries, optionsEntry{key: key})
+	}
+
+	checked := make(map[string]bool)
+	for _, e := range entries {
+		v, ok := optionMap[e.key]
+		if !ok || checked[e.key] {
+			continue
+		}
+		checked[e.key] = true
+
+		option := findRegisteredOption(e.key)
+		if option == nil {
+			report(e.offset, messages.FileUnknownOption, e.key)
+		} else if option.Flags&Preference == 0 {
+			report(e.offset, messages.FileNotPreference, e.key)
+		} else {
+			previous := option.plainString()
+			v, err := option.loadedValue(v)
+			if err == nil {
+				err = setJSONValue(option, v)
+			}
+			if err != nil {
+				report(e.offset, messages.FileInvalidValue, e.key, err.Error(), option.expectation())
+			} else if option.Flags&Locked > 0 && option.plainString() != previous {
+				report(e.offset, messages.FileOptionLocked, e.key)
+			}
+		}
+	}
+	return problems
+}
+
+// jsonErrorOffset returns the input offset where a JSON decoding error occurred
+func jsonErrorOffset(err error, dec *json.Decoder) int64 {
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		return e.Offset
+	case *json.UnmarshalTypeError:
+		return e.Offset
+	}
+	return dec.InputOffset()
+}
+
+// filePosition formats a byte offset in a file as name:line:column
+func filePosition(name string, data []byte, offset int64) string {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	line := 1 + bytes.Count(data[:offset], []byte("\n"))
+	col := int(offset) - bytes.LastIndexByte(data[:offset], '\n')
+	return fmt.Sprintf("%s:%d:%d", name, line, col)
+}
+
+/************************************* Commands *************************************/
+
+// CmdCommand is returned by the Command function and holds the full definition for a command
+type CmdCommand struct {
+	Command         string                         // Name of the command
+	Help            string                         // Help text to be displayed next to the command in Usage:
+	ShortHelp       string                         // Shorter help text displayed instead of Help in the summary shown by -h
+	Function        func()                         // Underlying function to be called when command is specified on commandline
+	Annotations     map[string]string              // Arbitrary metadata for generators of completion, docs or user interfaces
+	onUnknownOption func(name, value string) error // handler for options that have not been registered
+	run             func(context.Context) error    // function called instead of Function with the context of the parse
+	args            []string                       // arguments following the command name
+	parent          *CmdCommand                    // parent command of a child command added with SubCommand
+	requiredOptions []string                       // names of options required by the command
+	usesContext     bool                           // function was added with CommandContext
+	elevation       bool                           // command requires root or Administrator privileges
+	category        string                         // category that the command is listed under in help
+	examples        []string                       // example commandlines shown in the help of the command
+	usage           string                         // usage line replacing the generated one, without the program name
+	platforms       []string                       // operating systems the command is available on, all if empty
+	remainder       *[]string                      // receives the arguments following remainderAfter arguments
+	remainderAfter  int                            // number of arguments of the command before the remainder
+	location        string                         // source location where the command was added
+}
+
+// Category sets the category that the command is listed under in help, commands without a category are listed
+// first. Child commands are listed under the category of their parent unless they have a category of their own.
+//
+//	cmdparse.Command("backup", "<path>", backup).Category("Maintenance")
+//	cmdparse.Command("verify", "<path>", verify).Category("Maintenance")
+func (c *CmdCommand) Category(name string) *CmdCommand {
+	c.category = name
+	return c
+}
+
+// UsageLine replaces the usage line that is generated from the command name and Help in help and error messages.
+// The line follows the program name and should include the command name.
+//
+//	cmdparse.Command("copy", "Copy files", copy).UsageLine("copy [options] <source>... <dest>")
+func (c *CmdCommand) UsageLine(line string) *CmdCommand {
+	c.usage = line
+	return c
+}
+
+// RemainderOption captures all arguments that follow the first after arguments of the command in variable, exactly as
+// they were specified. Arguments in the remainder are never parsed as options, even if they start with -, so the user
+// does not have to separate them with --. The arguments are also included in Args.
+//
+//	var remote []string
+//	cmdparse.Command("exec", "<host> <command>...", execute).RemainderOption(1, &remote)
+//
+//	mytool exec myhost ls -la /tmp    (remote is ls, -la and /tmp)
+func (c *CmdCommand) RemainderOption(after int, variable *[]string) *CmdCommand {
+	c.remainder, c.remainderAfter = variable, after
+	return c
+}
+
+// Examples adds example commandlines to the command, shown in an Examples section of the help of the command.
+// A command with examples has help of its own, shown when a help flag follows the command name.
+//
+//	cmdparse.Command("copy", "<src> <dst>", copy).Examples(
+//	  "mytool copy src dst",
+//	  "mytool copy -ignore '*.tmp' src dst")
+func (c *CmdCommand) Examples(examples ...string) *CmdCommand {
+	c.examples = append(c.examples, examples...)
+	return c
+}
+
+// categoryName returns the category of the command or of its closest parent with a category
+func (c *CmdCommand) categoryName() string {
+	for ; c != nil; c = c.parent {
+		if c.category != "" {
+			return c.category
+		}
+	}
+	return ""
+}
+
+// Annotate sets a metadata annotation on the command, for use by completion, documentation or user interface
+// generators. The parser itself does not use annotations.
+//
+//	cmdparse.Command("push", "<path>", push).Annotate("requires-auth", "true").Annotate("stability", "beta")
+func (c *CmdCommand) Annotate(key string, value string) *CmdCommand {
+	if c.Annotations == nil {
+		c.Annotations = make(map[string]string)
+	}
+	c.Annotations[key] = value
+	return c
+}
+
+// Args returns the arguments that followed the command name on the commandline when the command was run.
+// For the default command all arguments that were not parsed are returned.
+func (c *CmdCommand) Args() []string {
+	return c.args
+}
+
+// matchCommand returns the command named by the first positional argument, the default command or nil if neither exists
+func matchCommand(args []string) *CmdCommand {
+	var command *CmdCommand
+	var matched int
+	for _, c := range commandList {
+		words := strings.Fields(c.Command)
+		if len(words) > len(args) || (command != nil && len(words) <= matched) {
+			continue
+		}
+		match := true
+		for i, w := range words {
+			if !commandWord(args[i], w) {
+				match = false
+				break
+			}
+		}
+		if match {
+			command, matched = c, len(words)
+		}
+	}
+	return command
+}
+
+// callerLocation returns the file and line of the first caller outside of this package
+func callerLocation() string {
+	pc := make([]uintptr, 16)
+	frames := runtime.CallersFrames(pc[:runtime.Callers(2, pc)])
+	pkg := reflect.TypeOf(CmdOption{}).PkgPath() + "."
+	for {
+		frame, more := frames.Next()
+		if !strings.HasPrefix(frame.Function, pkg) || !more {
+			return fmt.Sprintf("%s:%d", frame.File, frame.Line)
+		}
+	}
+}
+
+func findCommand(name string) *CmdCommand {
+	for _, c := range commandList {
+		if c.Command == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Command adds a command to the parser with the specified name, help text and function pointer.
+func Command(cmd string, help string, function func()) *CmdCommand {
+	c := CmdCommand{Command: cmd, Help: help, Function: function}
+	c.location = callerLocation()
+	for i, existing := range commandList {
+		if existing.Command == cmd {
+			if MustNotConflict {
+				panic(fmt.Sprintf("Command \"%s\" at %s conflicts with command added at %s", cmd, c.location, existing.location))
+			}
+			commandList[i] = &c
+			return &c
+		}
+	}
+	commandList = append(commandList, &c)
+	return &c
+}
+
+// SubCommand adds a child command to a parent command, making it possible to group commands in namespaces. The child
+// is specified on the commandline following the parent name and its option group is the full name, parent and child
+// separated by a space. A parent without a function only groups its children and prints focused help of itself and
+// its children if it is specified without a child. Specifying -h after a parent or child command also prints focused help.
+//
+//	config := cmdparse.Command("config", "<command>", nil)
+//	cmdparse.StringOption("file", "config", "<path>", "Configuration file", &configFile, cmdparse.Standard).Inherit()
+//	config.SubCommand("get", "<key>", configGet)
+//	config.SubCommand("set", "<key> <value>", configSet)
+//
+//	mytool config set -file=app.json color blue
+func (c *CmdCommand) SubCommand(cmd string, help string, function func()) *CmdCommand {
+	child := Command(c.Command+" "+cmd, help, function)
+	child.location = callerLocation()
+	child.parent = c
+	return child
+}
+
+// runnable returns true if the command has a function to run, other commands only group their child commands
+func (c *CmdCommand) runnable() bool {
+	return c.Function != nil || c.run != nil
+}
+
+// Run calls the function of the command the same way as when the command is dispatched by the parser and returns
+// its error. Commands that can fail, like the plugins added by DiscoverPlugins, have no Function and are run with Run.
+func (c *CmdCommand) Run() error {
+	if c.run != nil {
+		return c.run(context.Background())
+	} else if c.Function != nil {
+		c.Function()
+	}
+	return nil
+}
+
+func (c *CmdCommand) hasChildren() bool {
+	for _, n := range commandList {
+		if n.parent == c {
+			return true
+		}
+	}
+	return false
+}
+
+// isWithin returns true if c is the command p or one of its descendants
+func (c *CmdCommand) isWithin(p *CmdCommand) bool {
+	for ; c != nil; c = c.parent {
+		if c == p {
+			return true
+		}
+	}
+	return false
+}
+
+// RequireOptions makes existing options required when this command or one of its child commands is run, instead
+// of marking them Required for all commands. Options are checked by name when the command is dispatched.
+//
+//	cmdparse.Command("push", "<path>", push).RequireOptions("server", "accesskey")
+func (c *CmdCommand) RequireOptions(names ...string) *CmdCommand {
+	c.requiredOptions = append(c.requiredOptions, names...)
+	return c
+}
+
+// requires returns true if the option is required by the command or one of its parents
+func (c *CmdCommand) requires(o *CmdOption) bool {
+	for p := c; p != nil; p = p.parent {
+		for _, name := range p.requiredOptions {
+			if findScopedOption(name, c) == o {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// OnUnknownOption sets a handler that receives options that have not been registered when this command is run,
+// instead of failing with an invalid option error. Value is blank unless specified as -name=value.
+// This allows plugin like commands to accept arbitrary options while other commands keep strict checking.
+//
+//	defines := make(map[string]string)
+//	cmdparse.Command("build", "<target>", build).OnUnknownOption(func(name, value string) error {
+//	  if !strings.HasPrefix(name, "D") {
+//	    return errors.New("Invalid option -" + name)
+//	  }
+//	  defines[name[1:]] = value
+//	  return nil
+//	})
+func (c *CmdCommand) OnUnknownOption(f func(name, value string) error) *CmdCommand {
+	c.onUnknownOption = f
+	return c
+}
+
+/************************************* Options *************************************/
+
+// CmdOption is returned by each *Option support function and holds the full definition of a command option
+type CmdOption struct {
+	Name          string            // Name of option
+	Group         string            // Blank for global options or name of command for command specific options
+	Format        string            // A string explaining the accepted format like "<number>" or "<ip>:<port>"
+	Help          string            // Help text that describes the option
+	Value         Value             // Current value of the option
+	Default       string            // Defaul value if option is not specified
+	Flags         int               // Special option flags
+	Annotations   map[string]string // Arbitrary metadata for generators of completion, docs or user interfaces
+	onChange      func()            // function hook called when value changes
+	onChangeValue changeFunc        // function hook called with the old and new value when value changes
+	delivered     deliveredValue    // value last delivered to onChangeValue
+	onSave        func()            // function hook called before saving (encrypting passwords for example)
+	onSaveE       func() error      // function hook called before saving that can abort the save
+	saveTransform transform         // converts the value before it is saved
+	loadTransform transform         // converts the value after it is loaded
+	source        OptionSource      // where the current value was set from
+	aliases       [][2]string       // value aliases in registration order
+	defaultFunc   func() string     // computes the default value at parse time
+	min, max      *float64          // range of numeric values set with Min and Max
+	pattern       *regexp.Regexp    // pattern that values must match, set with MatchRegex
+	example       string            // example value shown in help and errors
+	hideDefault   bool              // default value is not shown in help
+	defaultText   string            // text shown in help instead of the default value
+	longHelp      string            // detailed help shown below Help in full help
+	platforms     []string          // operating systems the option is available on, all if empty
+	noPersist     bool              // loaded from but never saved to the options file
+	deprecated    *string           // deprecation message set with Deprecated
+	persistAlways bool              // saved to the options file even if it has not been changed
+	inherit       bool              // command option is also an option of the child commands
+	inheritValue  bool              // default is the value of the option with the same name outside the command
+	completer     completeFunc      // completes values for shell completion
+	duplicates    DuplicatePolicy   // what to do when the option is specified more than once
+	base, bits    int               // base and bit size of integer values set with Base and Bits
+	mru           int               // number of recent values kept in the options file
+	recent        []string          // recent values of an MRU option, most recent first
+	derivedFrom   []string          // names of options that the OnChange hook derives a value from
+	location      string            // source location where the option was added
+}
+
+// OptionSource describes where the current value of an option was set from
+type OptionSource int
+
+// Sources of option values
+const (
+	SourceDefault     OptionSource = iota // Option has its default value
+	SourceOptionsFile                     // Option was loaded from the options file
+	SourceCommandLine                     // Option was specified on the commandline
+	SourceProgram                         // Option was set by the application using Set
+	SourcePolicy                          // Option was set and locked by the policy file
+)
+
+func (s OptionSource) String() string {
+	switch s {
+	case SourceOptionsFile:
+		return messages.SourceOptionsFile
+	case SourceCommandLine:
+		return messages.SourceCommandLine
+	case SourceProgram:
+		return messages.SourceProgram
+	case SourcePolicy:
+		return messages.SourcePolicy
+	default:
+		return messages.SourceDefault
+	}
+}
+
+// Source returns where the current value of the option was set from
+func (c *CmdOption) Source() OptionSource {
+	return c.source
+}
+
+// Value is the interface to the value of an option. Custom option types can be added with VarOption by implementing
+// Value. If the type also implements json.Marshaler and json.Unmarshaler it is saved to and loaded from the options
+// file as any JSON value, including objects, otherwise the value returned by Get is saved and loaded through Set.
+type Value interface {
+	String() string   // Get current option in text format
+	Reset()           // Reset the option to default
+	Get() interface{} // Get the native value
+	Set(string) error // Set the native value from string
+}
+
+// OnChange is a hook called when an option value has been set
+// This can be used to convert option values
+//
+//	var sizeMB int64
+//	var byteSize int64
+//	cmdparse.IntOption("size", "", "<MiB>", "Set size", &sizeMB, cmdparse.Hidden|cmdparse.Preference).OnChange(func() {
+//	  byteSize = sizeMB * 1024 * 1024
+//	})
+//
+// Or set related options
+//
+//	var accesskey []byte
+//	var user string
+//	var password string
+//	cmdparse.ByteOption("accesskey", "", "", "Client accesskey", &accesskey, cmdparse.Preference|cmdparse.Hidden).OnSaveE(func() error {
+//	  if user == "" {
+//	    return errors.New("Unable to save login unless both user and password options are specified")
+//	  }
+//	  return nil
+//	})
+//	cmdparse.StringOption("user", "", "<username>", "Username", &user, cmdparse.Preference|cmdparse.Required)
+//	cmdparse.StringOption("password", "", "<password>", "Password", &password, cmdparse.Standard).OnChange(func() {
+//	  accesskey = GenerateAccessKey(user, password)
+//	})
+func (c *CmdOption) OnChange(f func()) *CmdOption {
+	c.onChange = f
+	return c
+}
+
+type changeFunc func(old, new interface{})
+
+type deliveredValue struct {
+	text  string
+	value interface{}
+}
+
+var changeMutex sync.Mutex // Guards delivered values so that concurrent changes are delivered once
+
+// OnChangeValue is a hook called with the previous and the new native value of the option when its value has changed.
+// Unlike OnChange, it is called exactly once for each effective change, setting an option to the value it already has
+// does not call the hook. Changes made from different goroutines, like ParseArgs and WatchOptionsFile, are delivered
+// one at a time so that each old value is the new value of the previous call.
+//
+//	cmdparse.IntOption("workers", "", "<count>", "Number of workers", &workers, cmdparse.Preference).OnChangeValue(func(old, new interface{}) {
+//	  log.Printf("Workers changed from %d to %d", old, new)
+//	})
+func (c *CmdOption) OnChangeValue(f func(old, new interface{})) *CmdOption {
+	changeMutex.Lock()
+	c.onChangeValue = f
+	c.delivered = deliveredValue{c.plainString(), c.Value.Get()}
+	changeMutex.Unlock()
+	return c
+}
+
+// OnSave is a hook called when an option value is about to be saved.
+// A panic with an error in the hook aborts saving and the error is returned, but OnSaveE is the preferred way to do that.
+func (c *CmdOption) OnSave(f func()) *CmdOption {
+	c.onSave = f
+	return c
+}
+
+// OnSaveE is a hook called when an option value is about to be saved. Returning an error aborts saving, the options
+// file is left untouched and the error is returned from Parse.
+// See OnChange for usage example.
+func (c *CmdOption) OnSaveE(f func() error) *CmdOption {
+	c.onSaveE = f
+	return c
+}
+
+// transform converts an option value when it is saved or loaded
+type transform func(v interface{}) (interface{}, error)
+
+// SaveTransform sets a function that converts the value of the option before it is saved to the options file, like
+// encrypting or hashing a password. The function receives the value that would otherwise be saved, normally the
+// value returned by Get, and returns the value to save instead. Returning an error aborts saving. Use LoadTransform
+// to convert the saved value back when it is loaded.
+//
+//	cmdparse.StringOption("password", "", "<password>", "Password", &password, cmdparse.Preference|cmdparse.Masked).
+//	  SaveTransform(func(v interface{}) (interface{}, error) {
+//	    return encrypt(v.(string))
+//	  }).
+//	  LoadTransform(func(v interface{}) (interface{}, error) {
+//	    return decrypt(v.(string))
+//	  })
+func (c *CmdOption) SaveTransform(f func(v interface{}) (interface{}, error)) *CmdOption {
+	c.saveTransform = f
+	return c
+}
+
+// LoadTransform sets a function that converts a value read from the options file before it is set to the option.
+// The function receives the decoded JSON value, a string, float64, bool, nil, []interface{} or map[string]interface{},
+// and returns a value of one of those types. An error is reported as an invalid value. See SaveTransform.
+func (c *CmdOption) LoadTransform(f func(v interface{}) (interface{}, error)) *CmdOption {
+	c.loadTransform = f
+	return c
+}
+
+// Annotate sets a metadata annotation on the option, for use by completion, documentation or user interface
+// generators. The parser itself does not use annotations.
+//
+//	cmdparse.StringOption("server", "", "<ip>:<port>", "Server address", &server, cmdparse.Preference).Annotate("complete", "hosts")
+func (c *CmdOption) Annotate(key string, value string) *CmdOption {
+	if c.Annotations == nil {
+		c.Annotations = make(map[string]string)
+	}
+	c.Annotations[key] = value
+	return c
+}
+
+// DefaultFunc sets a function that computes the default value of the option. The function is called each time the
+// commandline is parsed or help is displayed, so defaults like the number of CPUs or the current date are not frozen
+// when the option is added. The computed value is used unless the option is set from the options file or commandline.
+//
+//	var workers int64
+//	cmdparse.IntOption("workers", "", "<count>", "Number of workers", &workers, cmdparse.Preference).
+//	  DefaultFunc(func() string { return strconv.Itoa(runtime.NumCPU()) })
+func (c *CmdOption) DefaultFunc(f func() string) *CmdOption {
+	c.defaultFunc = f
+	return c
+}
+
+// resolveDefaults computes the defaults of options with a DefaultFunc and sets them on options that have not been set
+func resolveDefaults() {
+	for _, o := range optionList {
+		if o.defaultFunc == nil {
+			continue
+		}
+		d := o.defaultFunc()
+		if o.source == SourceDefault && o.plainString() == o.Default {
+			o.setString(d)
+		}
+		o.Default = d
+	}
+}
+
+// Deprecated marks the option as deprecated. The option still works but using it on the commandline or in the
+// options file adds a warning with the message, and the option is marked as deprecated in help.
+//
+//	cmdparse.StringOption("host", "", "<ip>", "Server address", &host, cmdparse.Hidden).Deprecated("use -server instead")
+func (c *CmdOption) Deprecated(message string) *CmdOption {
+	c.deprecated = &message
+	return c
+}
+
+func (c *CmdOption) warnDeprecated() {
+	if c.deprecated == nil {
+		return
+	} else if *c.deprecated == "" {
+		warn(WarningDeprecated, c.Name, messages.DeprecatedOption, displayPrefix+c.Name)
+	} else {
+		warn(WarningDeprecated, c.Name, messages.DeprecatedOptionReason, displayPrefix+c.Name, *c.deprecated)
+	}
+}
+
+// DuplicatePolicy decides what happens when an option is specified more than once on the commandline
+type DuplicatePolicy int
+
+// Policies for options specified more than once
+const (
+	DuplicateDefault DuplicatePolicy = iota // Lists and maps collect all values, other options use the last value with a warning
+	DuplicateLast                           // The last value is used without warning, lists and maps only keep the last value
+	DuplicateFirst                          // The first value is used and later values are ignored
+	DuplicateError                          // Specifying the option more than once is an error
+	DuplicateAppend                         // Lists and maps collect all values, other options use the last value without warning
+)
+
+// Duplicates sets what happens when the option is specified more than once on the commandline. Values from the
+// options file are always replaced by the commandline.
+//
+//	cmdparse.IntOption("size", "", "<MiB>", "Size", &size, cmdparse.Standard).Duplicates(cmdparse.DuplicateError)
+func (c *CmdOption) Duplicates(policy DuplicatePolicy) *CmdOption {
+	c.duplicates = policy
+	return c
+}
+
+// accumulates returns true for options that collect every value specified instead of replacing the value
+func (c *CmdOption) accumulates() bool {
+	switch c.Value.(type) {
+	case *stringListOption, *splitListOption, *intListOption, *floatListOption, *mapOption:
+		return true
+	}
+	return false
+}
+
+// Inherit makes a command option an option of the child commands of the command as well. Options of different
+// commands can share a name, an option of the command itself shadows inherited options of its parents and global
+// options of the same name when it is specified after the command name. Options with a shared name are saved in
+// the options file as "command.name" and help of a child command lists inherited options separately.
+//
+//	remote := cmdparse.Command("remote", "<command>", nil)
+//	cmdparse.StringOption("server", "remote", "<host>", "Remote server", &server, cmdparse.Preference).Inherit()
+//	remote.SubCommand("push", "<path>", push)
+//	remote.SubCommand("pull", "<path>", pull)
+//
+//	mytool remote push -server=backup.local /home
+func (c *CmdOption) Inherit() *CmdOption {
+	c.inherit = true
+	return c
+}
+
+// NoPersist excludes a Preference option from being saved with -saveoptions, while it is still loaded from the
+// options file. Saving keeps the value found in the options file, instead of the value set on the commandline.
+// This is useful for values that are managed by editing the options file by hand.
+func (c *CmdOption) NoPersist() *CmdOption {
+	c.noPersist = true
+	return c
+}
+
+// PersistAlways makes a Preference option always be saved with -saveoptions, even if it has its default value.
+// This documents the option in the options file and keeps the saved value if the default changes in a later version.
+func (c *CmdOption) PersistAlways() *CmdOption {
+	c.persistAlways = true
+	return c
+}
+
+// Example sets an example value that is shown in help and in the error when an invalid value is specified,
+// together with the Format of the option.
+//
+//	cmdparse.StringOption("server", "", "<ip>:<port>", "Server address", &server, cmdparse.Preference).Example("127.0.0.1:8080")
+//
+//	Invalid value set for option server: "localhost" (missing port), expected <ip>:<port>, e.g. 127.0.0.1:8080
+func (c *CmdOption) Example(example string) *CmdOption {
+	c.example = example
+	return c
+}
+
+// LongHelp sets a detailed description of the option that is shown below Help in the full help and the help of a
+// command, while the summary help only shows Help. Lines in the text are kept as separate paragraphs.
+//
+//	cmdparse.IntOption("compression", "", "<level>", "Compression level", &level, cmdparse.Standard).
+//	  LongHelp("Level 0 stores files without compression, 1 is the fastest and 9 gives the smallest files.\n" +
+//	    "Levels above 6 are rarely worth the extra time.")
+func (c *CmdOption) LongHelp(text string) *CmdOption {
+	c.longHelp = text
+	return c
+}
+
+// HideDefault leaves the default value out of the help of the option, for defaults that mean nothing to the user
+// like an empty string or 0.
+func (c *CmdOption) HideDefault() *CmdOption {
+	c.hideDefault = true
+	return c
+}
+
+// DefaultText sets a text that is shown in help instead of the default value, for defaults that are computed or
+// that have a special meaning.
+//
+//	cmdparse.IntOption("workers", "", "<n>", "Number of workers", &workers, cmdparse.Standard).
+//	  DefaultFunc(func() string { return strconv.Itoa(runtime.NumCPU()) }).DefaultText("auto-detected")
+func (c *CmdOption) DefaultText(text string) *CmdOption {
+	c.defaultText = text
+	return c
+}
+
+// SetFlags replaces the flags of the option, so that the flags can be decided after the option has been added.
+func (c *CmdOption) SetFlags(flags int) *CmdOption {
+	c.Flags = flags
+	return c
+}
+
+// AddFlags adds flags to the flags of the option.
+//
+//	cmdparse.StringOption("server", "", "<host>", "Server", &server, cmdparse.Standard).AddFlags(cmdparse.Required)
+func (c *CmdOption) AddFlags(flags int) *CmdOption {
+	c.Flags |= flags
+	return c
+}
+
+// ClearFlags removes flags from the flags of the option.
+//
+//	o := cmdparse.BoolOption("turbo", "", "Experimental turbo mode", &turbo, cmdparse.Hidden)
+//	if os.Getenv("MYTOOL_EXPERIMENTAL") != "" {
+//	  o.ClearFlags(cmdparse.Hidden)
+//	}
+func (c *CmdOption) ClearFlags(flags int) *CmdOption {
+	c.Flags &^= flags
+	return c
+}
+
+// expectation describes the expected format and an example value of the option for errors, or returns ""
+func (c *CmdOption) expectation() string {
+	var text string
+	if c.Format != "" {
+		text += fmt.Sprintf(messages.ExpectedFormat, c.Format)
+	}
+	if c.example != "" {
+		text += fmt.Sprintf(messages.ExpectedExample, c.example)
+	}
+	return text
+}
+
+// invalidValue returns the error for an invalid value specified for the option by name
+func (c *CmdOption) invalidValue(name string, value string, err error) error {
+	if _, locked := err.(*lockedError); locked {
+		return err
+	}
+	return errors.New(fmt.Sprintf(messages.InvalidValue, name, value, err.Error()) + c.expectation())
+}
+
+// Min sets the lowest value accepted by a numeric option. Setting a lower value fails with an error and the range
+// is shown in help. For IntList and FloatList options every value must be within the range.
+//
+//	cmdparse.IntOption("port", "", "<port>", "Listening port", &port, cmdparse.Preference).Min(1).Max(65535)
+func (c *CmdOption) Min(min float64) *CmdOption {
+	c.min = &min
+	return c
+}
+
+// Max sets the highest value accepted by a numeric option. Setting a higher value fails with an error and the range
+// is shown in help.
+func (c *CmdOption) Max(max float64) *CmdOption {
+	c.max = &max
+	return c
+}
+
+// Base sets the base that values of an integer option are parsed in, instead of detecting it from a 0x, 0o, 0b or
+// 0 prefix. With Base(10), "0755" is read as the decimal number 755 instead of the octal number 493.
+//
+//	cmdparse.IntOption("count", "", "<number>", "Number of copies", &count, cmdparse.Standard).Base(10)
+func (c *CmdOption) Base(base int) *CmdOption {
+	c.base = base
+	return c
+}
 
-				if command.Function != nil {
-					command.Function()
-				}
+// Bits sets the number of bits that values of an integer option must fit in, so that a value that is later stored
+// in a smaller integer type fails with an error instead of being truncated.
+//
+//	var port int64
+//	cmdparse.IntOption("port", "", "<port>", "Listening port", &port, cmdparse.Preference).Bits(16)
+func (c *CmdOption) Bits(bits int) *CmdOption {
+	c.bits = bits
+	return c
+}
 
-			} else {
-				if len(Args) < 2 {
-					Usage()
-					return errors.New("Missing required command")
-				} else {
-					return errors.New(Args[1] + " is not a valid command")
-				}
-			}
-		}
+func (c *CmdOption) bitSize() int {
+	if c.bits == 0 {
+		return 64
 	}
-	return nil
+	return c.bits
 }
 
-/************************************* Preferences Functions  *************************************/
+// intError describes why an integer value could not be parsed with the base and bit size of the option
+func (c *CmdOption) intError(value string, err error) error {
+	if e, ok := err.(*strconv.NumError); ok && e.Err == strconv.ErrRange {
+		return fmt.Errorf(messages.IntegerBits, c.bitSize())
+	} else if c.base != 0 {
+		return fmt.Errorf(messages.IntegerBase, c.base)
+	}
+	return errors.New(messages.NotInteger)
+}
 
-func jsonOptions() ([]byte, error) {
-	optionMap := make(map[string]interface{})
-	for _, v := range optionList {
-		if (v.Flags&Preference > 0) && v.Value.String() != v.Default {
-			v.doSave()
-			optionMap[v.Name] = v.Value.Get()
+// MatchRegex sets a regular expression that values must match. For list options each added value must match.
+// MatchRegex panics if the pattern cannot be compiled.
+//
+//	cmdparse.StringOption("user", "", "<name>", "User name", &user, cmdparse.Standard).MatchRegex("^[a-z][a-z0-9]*$")
+func (c *CmdOption) MatchRegex(pattern string) *CmdOption {
+	c.pattern = regexp.MustCompile(pattern)
+	return c
+}
+
+// ValueAlias registers an alias for an option value. The alias is expanded to the canonical value before the value
+// is set, both on the commandline and when loading the options file, and all aliases are listed in help.
+//
+//	var region string
+//	cmdparse.StringOption("region", "", "<region>", "Server region", &region, cmdparse.Preference).
+//	  ValueAlias("eu", "eu-central-1").ValueAlias("us", "us-east-1")
+func (c *CmdOption) ValueAlias(alias string, value string) *CmdOption {
+	c.aliases = append(c.aliases, [2]string{alias, value})
+	return c
+}
+
+// set expands value aliases and sets the option value
+func (c *CmdOption) set(value string) error {
+	for _, a := range c.aliases {
+		if a[0] == value {
+			value = a[1]
+			break
 		}
 	}
-
-	jsonData, err := json.MarshalIndent(optionMap, "", "\t")
-	if err != nil {
-		return nil, err
+	value = c.localeValue(value)
+	value = c.digitValue(value)
+	if c.pattern != nil && !c.pattern.MatchString(value) {
+		return fmt.Errorf(messages.NoPatternMatch, c.pattern.String())
+	}
+	if c.base != 0 || c.bits != 0 {
+		v, err := strconv.ParseInt(value, c.base, c.bitSize())
+		if err != nil {
+			return c.intError(value, err)
+		}
+		value = strconv.FormatInt(v, 10)
+	}
+	if c.min == nil && c.max == nil {
+		return c.Value.Set(value)
 	}
 
-	return jsonData, nil
+	previous := c.plainString()
+	if err := c.Value.Set(value); err != nil {
+		return err
+	}
+	if err := c.checkRange(); err != nil {
+		c.setString(previous)
+		return err
+	}
+	return nil
 }
 
-func saveOptions(name string) (string, error) {
-	jsonData, err := jsonOptions()
-
-	err = os.MkdirAll(filepath.Dir(name), 0700)
-	if err != nil {
-		return "", err
+// checkRange checks the value against Min and Max, every element is checked for IntList and FloatList options
+func (c *CmdOption) checkRange() error {
+	var values []float64
+	switch l := c.Value.Get().(type) {
+	case []int64:
+		for _, i := range l {
+			values = append(values, float64(i))
+		}
+	case []float64:
+		values = l
+	default:
+		v, err := strconv.ParseFloat(c.plainString(), 64)
+		if err != nil {
+			return errors.New(messages.NotNumber)
+		}
+		values = []float64{v}
 	}
-	file, err := os.OpenFile(name, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0700)
-	if err != nil {
-		return "", err
+	for _, v := range values {
+		if c.min != nil && v < *c.min {
+			return fmt.Errorf(messages.BelowMinimum, formatFloat(*c.min))
+		}
+		if c.max != nil && v > *c.max {
+			return fmt.Errorf(messages.AboveMaximum, formatFloat(*c.max))
+		}
 	}
-	defer file.Close()
-	file.Write(jsonData)
-	return string(jsonData), nil
+	return nil
 }
 
-func loadOptions(name string) error {
-	file, err := os.OpenFile(name, os.O_RDONLY, 0700)
-	if err != nil {
-		return nil
+// rangeText describes the constraints set with Min, Max and MatchRegex for help, or returns "" if there are none
+func (c *CmdOption) rangeText() string {
+	var text string
+	if c.min != nil && c.max != nil {
+		text = " " + fmt.Sprintf(messages.Range, formatFloat(*c.min), formatFloat(*c.max))
+	} else if c.min != nil {
+		text = " " + fmt.Sprintf(messages.Min, formatFloat(*c.min))
+	} else if c.max != nil {
+		text = " " + fmt.Sprintf(messages.Max, formatFloat(*c.max))
 	}
-	defer file.Close()
+	if c.pattern != nil {
+		text += " " + fmt.Sprintf(messages.Matching, c.pattern.String())
+	}
+	return text
+}
 
-	var optionMap map[string]interface{}
-	if err := json.NewDecoder(file).Decode(&optionMap); err != nil {
-		return err
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// apply sets the option from a string value and records the source, an empty value resets the option
+func (c *CmdOption) apply(value string, source OptionSource) error {
+	if c.Flags&Locked > 0 && source != SourceProgram && source != SourcePolicy {
+		previous := c.plainString()
+		if value == "" {
+			c.Value.Reset()
+		} else if err := c.set(value); err != nil {
+			return err
+		}
+		if c.plainString() != previous {
+			c.setString(previous)
+			return c.lockedError()
+		}
+		return nil
 	}
-	for _, o := range optionList {
-		if v, ok := optionMap[o.Name]; ok {
-			switch t := v.(type) {
-			case nil: // for JSON null
-				o.Value.Reset()
-			case map[string]interface{}: // for JSON objects
-			// not implemented
-			case []interface{}: // for JSON arrays
-				o.Value.Reset()
-				for _, s := range t {
-					if err := o.Value.Set(s.(string)); err != nil {
-						return err
-					}
-				}
-				o.doChange()
-			default:
-				if err := o.Value.Set(fmt.Sprintf("%v", t)); err != nil {
-					return err
-				}
-				o.doChange()
-			}
+	if value == "" {
+		c.Value.Reset()
+		c.source = source
+		emitEvent(OptionReset, c)
+	} else {
+		if err := c.set(value); err != nil {
+			return err
 		}
+		c.source = source
+		emitEvent(OptionChanged, c)
 	}
+	c.doChange()
 	return nil
 }
 
-/************************************* Commands *************************************/
-
-// CmdCommand is returned by the Command function and holds the full definition for a command 
-type CmdCommand struct {
-	Command  string 	// Name of the command
-	Help     string 	// Help text to be displayed next to the command in Usage:
-	Function func() 	// Underlying function to be called when command is specified on commandline
+// traceSet reports the current value of the option to the trace function
+func (c *CmdOption) traceSet(index int) {
+	if traceFunc == nil {
+		return
+	}
+	value := c.Value.String()
+	if c.masked() {
+		value = maskedValue
+	}
+	trace(ParseEvent{Type: TraceOptionSet, Name: c.Name, Value: value, Source: c.source, Index: index})
 }
 
-// Command adds a command to the parser with the specified name, help text and function pointer.
-func Command(cmd string, help string, function func()) *CmdCommand {
-	c := CmdCommand{Command: cmd, Help: help, Function: function}
-	commandList = append(commandList, &c)
-	return &c
+// setString sets the option from a string in the same format as returned by Value.String()
+func (c *CmdOption) setString(s string) error {
+	c.Value.Reset()
+	if s == "" {
+		return nil
+	}
+	if f, ok := c.Value.(interface {
+		FromString(string) error
+	}); ok {
+		return f.FromString(s)
+	}
+	return c.Value.Set(s)
 }
 
-/************************************* Options *************************************/
+type optionState struct {
+	option *CmdOption
+	value  string
+	source OptionSource
+}
 
-// CmdOption is returned by each *Option support function and holds the full definition of a command option
-type CmdOption struct {
-	Name     string 	// Name of option
-	Group    string 	// Blank for global options or name of command for command specific options
-	Format   string 	// A string explaining the accepted format like "<number>" or "<ip>:<port>"
-	Help     string 	// Help text that describes the option
-	Value    optionValue 	// Current value of the option
-	Default  string // Defaul value if option is not specified
-	Flags    int // Special option flags 
-	onChange func() 	// function hook called when value changes
-	onSave   func() 	// function hook called before saving (encrypting passwords for example)
+// snapshotValues captures the current value and source of all options
+func snapshotValues() []optionState {
+	var states []optionState
+	for _, o := range optionList {
+		states = append(states, optionState{option: o, value: o.plainString(), source: o.source})
+	}
+	return states
 }
 
-type optionValue interface {
-	String() string 	// Get current option in text format
-	Reset()				// Reset the option to default
-	Get() interface{} 	// Get the native value
-	Set(string) error 	// Set the native value from string
+// restoreValues sets all options back to a previously captured state, calling OnChange hooks for values that differ
+func restoreValues(states []optionState) {
+	for _, s := range states {
+		s.option.source = s.source
+		if s.option.plainString() != s.value {
+			s.option.setString(s.value)
+			emitEvent(OptionChanged, s.option)
+			s.option.doChange()
+		}
+	}
 }
 
-// OnChange is a hook called when an option value has been set
-// This can be used to convert option values
-//   var sizeMB int64
-//   var byteSize int64
-//   cmdparse.IntOption("size", "", "<MiB>", "Set size", &sizeMB, cmdparse.Hidden|cmdparse.Preference).OnChange(func() {
-//     byteSize = sizeMB * 1024 * 1024
-//   })
-//
-// Or set related options
-//
-//   var accesskey []byte
-//   var user string
-//   var password string
-//   cmdparse.ByteOption("accesskey", "", "", "Client accesskey", &accesskey, cmdparse.Preference|cmdparse.Hidden)
-//   cmdparse.StringOption("user", "", "<username>", "Username", &user, cmdparse.Preference|cmdparse.Required)
-//   cmdparse.StringOption("password", "", "<password>", "Password", &password, cmdparse.Standard).OnChange(func() {
-//     accesskey := GenerateAccessKey(user, password)
-//   }).OnSave(func() {
-//     if user == "" {
-//       panic(errors.New("Unable to save login unless both user and password options are specified"))
-//     }
-//   })
-func (c *CmdOption) OnChange(f func()) *CmdOption {
-	c.onChange = f
-	return c
+// changed returns true if the option was explicitly set, even to its default value, or if its variable was changed
+// directly by the application
+func (c *CmdOption) changed() bool {
+	return c.source != SourceDefault || c.plainString() != c.Default
 }
 
-// OnSave is a hook called when an option value is about to be saved.
-// See OnChange for usage example.
-func (c *CmdOption) OnSave(f func()) *CmdOption {
-	c.onSave = f
-	return c
+// persisted returns true if the option is saved to the options file
+func (c *CmdOption) persisted() bool {
+	return c.Flags&Preference > 0 && c.Flags&Locked == 0 && !c.noPersist && (c.persistAlways || c.changed())
 }
 
 func (c *CmdOption) doChange() {
-	if c.onChange != nil {
+	if deferred := changeDerived(c); c.onChange != nil && !deferred {
 		c.onChange()
 	}
+	if c.onChangeValue != nil {
+		changeMutex.Lock()
+		old, current := c.delivered, deliveredValue{c.plainString(), c.Value.Get()}
+		c.delivered = current
+		changeMutex.Unlock()
+		if old.text != current.text {
+			c.onChangeValue(old.value, current.value)
+		}
+	}
 }
-func (c *CmdOption) doSave() {
+
+// doSave calls the save hooks, a panic in an OnSave hook is returned as an error
+func (c *CmdOption) doSave() (err error) {
 	if c.onSave != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				if e, ok := r.(error); ok {
+					err = e
+				} else {
+					err = fmt.Errorf("%v", r)
+				}
+			}
+		}()
 		c.onSave()
 	}
+	if c.onSaveE != nil {
+		return c.onSaveE()
+	}
+	return nil
 }
 
 type boolOption bool
@@ -415,6 +2857,25 @@ func (b *boolOption) Set(s string) error {
 	return err
 }
 
+// boolPtrOption is a bool that is nil until it has been set
+type boolPtrOption struct{ variable **bool }
+
+func (b boolPtrOption) String() string {
+	if *b.variable == nil {
+		return ""
+	}
+	return strconv.FormatBool(**b.variable)
+}
+func (b boolPtrOption) Reset()           { *b.variable = nil }
+func (b boolPtrOption) Get() interface{} { return *b.variable }
+func (b boolPtrOption) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err == nil {
+		*b.variable = &v
+	}
+	return err
+}
+
 type intOption int64
 
 func (i *intOption) String() string   { return fmt.Sprintf("%v", *i) }
@@ -447,6 +2908,22 @@ func (s *stringOption) Set(v string) error {
 	return nil
 }
 
+// stringPtrOption is a string that is nil until it has been set
+type stringPtrOption struct{ variable **string }
+
+func (s stringPtrOption) String() string {
+	if *s.variable == nil {
+		return ""
+	}
+	return **s.variable
+}
+func (s stringPtrOption) Reset()           { *s.variable = nil }
+func (s stringPtrOption) Get() interface{} { return *s.variable }
+func (s stringPtrOption) Set(v string) error {
+	*s.variable = &v
+	return nil
+}
+
 type stringListOption []string
 
 func (s *stringListOption) String() string {
@@ -467,6 +2944,90 @@ func (s *stringListOption) Set(v string) error {
 	return nil
 }
 
+type intListOption []int64
+
+func (l *intListOption) String() string {
+	if *l == nil {
+		return ""
+	}
+	j, _ := json.Marshal([]int64(*l))
+	return string(j)
+}
+func (l *intListOption) FromString(v string) error {
+	return json.Unmarshal([]byte(v), l)
+}
+func (l *intListOption) Reset()           { *l = nil }
+func (l *intListOption) Get() interface{} { return []int64(*l) }
+func (l *intListOption) Set(v string) error {
+	i, err := strconv.ParseInt(v, 0, 64)
+	if err == nil {
+		*l = append(*l, i)
+	}
+	return err
+}
+
+type floatListOption []float64
+
+func (l *floatListOption) String() string {
+	if *l == nil {
+		return ""
+	}
+	j, _ := json.Marshal([]float64(*l))
+	return string(j)
+}
+func (l *floatListOption) FromString(v string) error {
+	return json.Unmarshal([]byte(v), l)
+}
+func (l *floatListOption) Reset()           { *l = nil }
+func (l *floatListOption) Get() interface{} { return []float64(*l) }
+func (l *floatListOption) Set(v string) error {
+	f, err := strconv.ParseFloat(v, 64)
+	if err == nil {
+		*l = append(*l, f)
+	}
+	return err
+}
+
+type splitListOption []string
+
+func (s *splitListOption) String() string            { return (*stringListOption)(s).String() }
+func (s *splitListOption) FromString(v string) error { return (*stringListOption)(s).FromString(v) }
+func (s *splitListOption) Reset()                    { *s = nil }
+func (s *splitListOption) Get() interface{}          { return []string(*s) }
+func (s *splitListOption) Set(v string) error {
+	list, err := splitList(v)
+	*s = append(*s, list...)
+	return err
+}
+
+type mapOption map[string]string
+
+func (m *mapOption) String() string {
+	if *m == nil {
+		return ""
+	}
+	j, _ := json.Marshal(map[string]string(*m))
+	return string(j)
+}
+func (m *mapOption) FromString(v string) error {
+	return json.Unmarshal([]byte(v), m)
+}
+func (m *mapOption) Reset()           { *m = nil }
+func (m *mapOption) Get() interface{} { return map[string]string(*m) }
+func (m *mapOption) Set(v string) error {
+	pair := strings.SplitN(v, "=", 2)
+	if len(pair) < 2 {
+		return errors.New(messages.KeyValueFormat)
+	}
+	if *m == nil {
+		*m = make(map[string]string)
+	}
+	(*m)[pair[0]] = pair[1]
+	return nil
+}
+
+const byteFilePrefix = "file://" // Prefix for loading a byte option value from a file on the commandline
+
 type byteOption []byte
 
 func (b *byteOption) String() string   { return base64.StdEncoding.EncodeToString(*b) }
@@ -477,59 +3038,329 @@ func (b *byteOption) Set(s string) error {
 	*b = byteOption(v)
 	return err
 }
+func (b *byteOption) encode(data []byte) string {
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// Lookup returns the option with the specified name or nil if there is no such option
+func Lookup(name string) *CmdOption {
+	return findOption(name)
+}
+
+// Set sets the value of the named option from a string, the same way as if it was specified on the commandline.
+// An empty value resets the option.
+func Set(name string, value string) error {
+	o := findOption(name)
+	if o == nil {
+		return fmt.Errorf(messages.InvalidOption, displayPrefix+name)
+	}
+	if err := o.apply(value, SourceProgram); err != nil {
+		return o.invalidValue(name, value, err)
+	}
+	o.traceSet(0)
+	return nil
+}
+
+// Visit calls the function for every option in the order they were added
+func Visit(f func(option *CmdOption)) {
+	for _, o := range optionList {
+		f(o)
+	}
+}
+
+// SetNameNormalizer sets a function that is applied to both registered option names and option names specified
+// on the commandline before they are compared. This makes it possible to accept different spellings of the same
+// option without registering aliases.
+//
+//	cmdparse.SetNameNormalizer(func(name string) string {
+//	  return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(name))
+//	})
+func SetNameNormalizer(f func(name string) string) {
+	nameNormalizer = f
+	invalidateIndex()
+}
+
+func normalizeName(name string) string {
+	if nameNormalizer != nil {
+		return nameNormalizer(name)
+	}
+	return name
+}
+
+// optionIndex maps names to options to avoid scanning all options for every argument
+type optionIndex struct {
+	byName map[string][]*CmdOption // options by normalized name in the order they were added
+	byKey  map[string]*CmdOption   // options by options file key
+	folded map[string]*CmdOption   // options by folded options file key, nil if several keys fold the same
+	names  map[string]int          // number of options with each name
+}
+
+var index *optionIndex // built when needed, cleared when options or the name normalizer change
+var indexMutex sync.Mutex
+
+// invalidateIndex makes the next lookup rebuild the option index
+func invalidateIndex() {
+	indexMutex.Lock()
+	index = nil
+	indexMutex.Unlock()
+}
+
+// lookupIndex returns the option index, building it if needed
+func lookupIndex() *optionIndex {
+	indexMutex.Lock()
+	defer indexMutex.Unlock()
+	if index != nil {
+		return index
+	}
+	index = &optionIndex{byName: make(map[string][]*CmdOption), byKey: make(map[string]*CmdOption), folded: make(map[string]*CmdOption), names: make(map[string]int)}
+	for _, o := range optionList {
+		name := normalizeName(o.Name)
+		index.byName[name] = append(index.byName[name], o)
+		index.names[o.Name]++
+	}
+	for _, o := range optionList {
+		if _, exists := index.byKey[index.key(o)]; !exists {
+			index.byKey[index.key(o)] = o
+		}
+	}
+	for key, o := range index.byKey {
+		if _, exists := index.folded[foldKey(key)]; exists {
+			index.folded[foldKey(key)] = nil
+		} else {
+			index.folded[foldKey(key)] = o
+		}
+	}
+	return index
+}
+
+// key returns the options file key of an option, command options that share their name with another option are
+// qualified by the command name as "command.name"
+func (x *optionIndex) key(o *CmdOption) string {
+	if o.Group != "" && (nestedGroups || x.names[o.Name] > 1) {
+		return o.Group + "." + o.Name
+	}
+	return o.Name
+}
+
+// findRegisteredOption returns the option with exactly the specified options file key or nil if there is no such option
+func findRegisteredOption(key string) *CmdOption {
+	return lookupIndex().byKey[key]
+}
+
+// foldKey returns the options file key in lower case without dashes and underscores, for matching hand-edited keys
+func foldKey(key string) string {
+	return strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(key))
+}
+
+// normalizeEntries changes options file keys that only differ from the key of an option in case, dashes or
+// underscores to the key of the option, unless the file also has the exact key. The normalized function is called
+// for each changed entry with its new key.
+func normalizeEntries(entries []optionsEntry, normalized func(e optionsEntry, key string)) {
+	keys := make(map[string]bool)
+	for _, e := range entries {
+		keys[e.key] = true
+	}
+	x := lookupIndex()
+	for i, e := range entries {
+		if x.byKey[e.key] != nil {
+			continue
+		}
+		if o := x.folded[foldKey(e.key)]; o != nil && !keys[x.key(o)] {
+			normalized(e, x.key(o))
+			entries[i].key = x.key(o)
+		}
+	}
+}
+
+// findOption returns the option matching a name from the commandline or nil if there is no such option.
+// A name shared by several options finds the global one, a command option can also be found by its qualified key.
+func findOption(name string) *CmdOption {
+	if o := findScopedOption(name, nil); o != nil {
+		return o
+	}
+	return findRegisteredOption(name)
+}
+
+// findScopedOption returns the option matching a name from the commandline as seen from the command scope. When
+// several options share the name, the option of scope itself is found first, then inherited options of its parents
+// from the closest one and last the global option.
+func findScopedOption(name string, scope *CmdCommand) *CmdOption {
+	matches := lookupIndex().byName[normalizeName(name)]
+	if len(matches) == 0 {
+		return nil
+	} else if len(matches) == 1 {
+		return matches[0]
+	}
+	for c := scope; c != nil; c = c.parent {
+		for _, o := range matches {
+			if o.Group == c.Command && (c == scope || o.inherit) {
+				return o
+			}
+		}
+	}
+	for _, o := range matches {
+		if o.Group == "" {
+			return o
+		}
+	}
+	return matches[0]
+}
+
+// key returns the name of the option in the options file. Command options that share their name with another
+// option are qualified by the command name as "command.name".
+func (c *CmdOption) key() string {
+	return lookupIndex().key(c)
+}
 
-func addOption(name string, cmd string, format string, help string, variable optionValue, flags int) *CmdOption {
+func addOption(name string, cmd string, format string, help string, variable Value, flags int) *CmdOption {
 	o := CmdOption{Name: name, Group: cmd, Format: format, Help: help, Value: variable, Default: variable.String(), Flags: flags}
+	o.location = callerLocation()
+	for i, existing := range optionList {
+		if existing.Group == cmd && (existing.Name == name || normalizeName(existing.Name) == normalizeName(name)) {
+			if MustNotConflict {
+				panic(fmt.Sprintf("Option -%s at %s conflicts with option -%s added at %s", name, o.location, existing.Name, existing.location))
+			}
+			optionList[i] = &o
+			invalidateIndex()
+			emitEvent(OptionRegistered, &o)
+			return &o
+		}
+	}
 	optionList = append(optionList, &o)
+	invalidateIndex()
+	emitEvent(OptionRegistered, &o)
 	return &o
 }
 
 // BoolOption adds a bool option with the specified name, command group, help text, variable pointer and flags
 // Boolean option uses the strconv.ParseBool function an accepts 1, t, T, TRUE, true, True, 0, f, F, FALSE, false, False. Any other value returns an error.
 // Specifying a boolean option on commandline with no value is the same as true
-//   var beVerbose bool
-//   cmdparse.BoolOption("verbose", "", "Show verbose output", &beVerbose, cmdparse.Preference)
+//
+//	var beVerbose bool
+//	cmdparse.BoolOption("verbose", "", "Show verbose output", &beVerbose, cmdparse.Preference)
 func BoolOption(name string, cmd string, help string, variable *bool, flags int) *CmdOption {
 	return addOption(name, cmd, "", help, (*boolOption)(variable), flags)
 }
 
+// BoolPtrOption adds a bool option that is nil until it is set, with the specified name, command group, help text,
+// variable pointer and flags. This makes it possible to tell an option that was set to false from one that was
+// never set, when merging commandline values over other configuration. It is parsed like a BoolOption, an empty
+// value sets it back to nil and nil is saved as null in the options file.
+//
+//	var compress *bool
+//	cmdparse.BoolPtrOption("compress", "", "Compress data, overrides the server setting", &compress, cmdparse.Standard)
+//	if compress != nil {
+//	  config.Compress = *compress
+//	}
+func BoolPtrOption(name string, cmd string, help string, variable **bool, flags int) *CmdOption {
+	return addOption(name, cmd, "", help, boolPtrOption{variable}, flags)
+}
+
 // IntOption adds an integer option with the specified name, command group, help text, variable pointer and flags
 // Integer options uses the 64 bit strconv.ParseInt function and accepts "0x" prefix for base 16, "0" prefix for base 8
-// and uses base 10 otherwise. 
-//   var size int64
-//   cmdparse.IntOption("size", "truncate", "<MiB>", "Size to truncate to", &size, cmdparse.Preference|cmdparse.Required)
+// and uses base 10 otherwise.
+//
+//	var size int64
+//	cmdparse.IntOption("size", "truncate", "<MiB>", "Size to truncate to", &size, cmdparse.Preference|cmdparse.Required)
 func IntOption(name string, cmd string, format string, help string, variable *int64, flags int) *CmdOption {
 	return addOption(name, cmd, format, help, (*intOption)(variable), flags)
 }
 
 // FloatOption adds a float option with the specified name, command group, help text, variable pointer and flags
 // Float options uses the 64 bit strconv.ParseFloat function and accepts a well-formed floating point number that is rounded using IEEE754 unbiased rounding.
-//   var q float64
-//   cmdparse.FloatOption("q", "", "<value>", "Sets the filter q value", &q, cmdparse.Standard)
+//
+//	var q float64
+//	cmdparse.FloatOption("q", "", "<value>", "Sets the filter q value", &q, cmdparse.Standard)
 func FloatOption(name string, cmd string, format string, help string, variable *float64, flags int) *CmdOption {
 	return addOption(name, cmd, format, help, (*floatOption)(variable), flags)
 }
 
 // StringOption adds a string option with the specified name, command group, help text, variable pointer and flags
-//   var serverAddr string
-//   cmdparse.StringOption("server", "", "<ip>:<port>", "Server address", &serverAddr, cmdparse.Preference|cmdparse.Required)
+//
+//	var serverAddr string
+//	cmdparse.StringOption("server", "", "<ip>:<port>", "Server address", &serverAddr, cmdparse.Preference|cmdparse.Required)
 func StringOption(name string, cmd string, format string, help string, variable *string, flags int) *CmdOption {
 	return addOption(name, cmd, format, help, (*stringOption)(variable), flags)
 }
 
+// StringPtrOption adds a string option that is nil until it is set, with the specified name, command group, help
+// text, variable pointer and flags. Unlike a StringOption, an option that was never set can be told from one that
+// was set to an empty string in the options file. An empty value on the commandline sets it back to nil.
+//
+//	var proxy *string
+//	cmdparse.StringPtrOption("proxy", "", "<url>", "Proxy server, overrides the system setting", &proxy, cmdparse.Preference)
+func StringPtrOption(name string, cmd string, format string, help string, variable **string, flags int) *CmdOption {
+	return addOption(name, cmd, format, help, stringPtrOption{variable}, flags)
+}
+
 // StringListOption adds a string list option with the specified name, command group, help text, variable pointer and flags
-// Specifying a StringListOption on commandline will add that string to the internal list. There is no way to remove strings from the list from commandline except resetting the list by specifying an empty value.
+// Specifying a StringListOption on commandline will add that string to the internal list. A string is removed from the
+// list with -name-=string and the list is replaced with -name==string, resetting the list by specifying an empty value.
 // StringList options uses json.Unmarshal to format json type arrays when saving and loading to options file.
-//   var IgnoreList []string
-//   cmdparse.StringListOption("ignore", "copy", "<pattern>", "Ignore files matching pattern", &IgnoreList, cmdparse.Standard|cmdparse.Preference)
+//
+//	var IgnoreList []string
+//	cmdparse.StringListOption("ignore", "copy", "<pattern>", "Ignore files matching pattern", &IgnoreList, cmdparse.Standard|cmdparse.Preference)
 func StringListOption(name string, cmd string, format string, help string, variable *[]string, flags int) *CmdOption {
 	return addOption(name, cmd, format, help, (*stringListOption)(variable), flags)
 }
 
+// IntListOption adds an integer list option with the specified name, command group, help text, variable pointer and flags
+// Each time the option is specified on the commandline the value is parsed like an IntOption and added to the list,
+// an empty value resets the list. IntList options are saved and loaded as JSON arrays of numbers in the options file.
+//
+//	var ports []int64
+//	cmdparse.IntListOption("port", "", "<port>", "Listen on port", &ports, cmdparse.Preference)
+func IntListOption(name string, cmd string, format string, help string, variable *[]int64, flags int) *CmdOption {
+	return addOption(name, cmd, format, help, (*intListOption)(variable), flags)
+}
+
+// FloatListOption adds a float list option with the specified name, command group, help text, variable pointer and flags
+// Each time the option is specified on the commandline the value is parsed like a FloatOption and added to the list,
+// an empty value resets the list. FloatList options are saved and loaded as JSON arrays of numbers in the options file.
+//
+//	var weights []float64
+//	cmdparse.FloatListOption("weight", "", "<value>", "Add a weight", &weights, cmdparse.Standard)
+func FloatListOption(name string, cmd string, format string, help string, variable *[]float64, flags int) *CmdOption {
+	return addOption(name, cmd, format, help, (*floatListOption)(variable), flags)
+}
+
+// SplitListOption adds a string list option that works like StringListOption but also accepts several values in
+// one argument, separated by comma or the OS path list separator (; on Windows and : elsewhere). Values containing
+// separators can be quoted with " or ', and outside of Windows a backslash escapes the next character.
+//
+//	var IgnoreList []string
+//	cmdparse.SplitListOption("ignore", "copy", "<pattern>[,<pattern>...]", "Ignore files matching patterns", &IgnoreList, cmdparse.Preference)
+//
+//	mytool copy -ignore "*.tmp,*.log" -ignore "'a,b.txt'" src dst
+func SplitListOption(name string, cmd string, format string, help string, variable *[]string, flags int) *CmdOption {
+	return addOption(name, cmd, format, help, (*splitListOption)(variable), flags)
+}
+
+// MapOption adds a string map option with the specified name, command group, help text, variable pointer and flags
+// Each time the option is specified on the commandline a key=value pair is added to the map, an empty value resets
+// the map. Map options are saved and loaded as JSON objects in the options file.
+//
+//	var labels map[string]string
+//	cmdparse.MapOption("label", "", "<key>=<value>", "Add a label", &labels, cmdparse.Preference)
+//
+//	mytool -label env=prod -label team=backend
+func MapOption(name string, cmd string, format string, help string, variable *map[string]string, flags int) *CmdOption {
+	return addOption(name, cmd, format, help, (*mapOption)(variable), flags)
+}
+
+// VarOption adds an option with a custom Value implementation, using the specified name, command group, help text
+// and flags. The default is the String value at the time the option is added.
+func VarOption(name string, cmd string, format string, help string, value Value, flags int) *CmdOption {
+	return addOption(name, cmd, format, help, value, flags)
+}
+
 // ByteOption adds a byte option with the specified name, command group, help text, variable pointer and flags
 // Byte options uses base64 standard encoding when specified on commandline and saving/loading from options file.
-//   var accesskey []byte
-//	 cmdparse.ByteOption("accesskey", "", "", "Client accesskey", &accesskey, cmdparse.Preference|cmdparse.Hidden)
+// A value with a file:// prefix on the commandline loads the raw bytes from the file instead, like -key=file://key.bin
+// Use Encoding to select hex or raw text instead of base64.
+//
+//	var accesskey []byte
+//	cmdparse.ByteOption("accesskey", "", "", "Client accesskey", &accesskey, cmdparse.Preference|cmdparse.Hidden)
 func ByteOption(name string, cmd string, format string, help string, variable *[]byte, flags int) *CmdOption {
 	return addOption(name, cmd, format, help, (*byteOption)(variable), flags)
 }
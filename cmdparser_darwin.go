@@ -0,0 +1,30 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build darwin
+// +build darwin
+
+package cmdparser
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const defaultEditor = "vi" // Editor used by -editoptions when neither VISUAL nor EDITOR is set
+
+// UserHomeFolder is a simple cross-platform function to retrieve the users home path using environment variables.
+func UserHomeFolder() string {
+	return os.Getenv("HOME")
+}
+
+// UserConfigFolder returns the folder for user specific configuration files, ~/Library/Application Support
+func UserConfigFolder() string {
+	return filepath.Join(UserHomeFolder(), "Library", "Application Support")
+}
+
+// UserCacheFolder returns the folder for user specific cached data, ~/Library/Caches
+func UserCacheFolder() string {
+	return filepath.Join(UserHomeFolder(), "Library", "Caches")
+}
@@ -0,0 +1,31 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build js
+// +build js
+
+package cmdparser
+
+import (
+	"os"
+	"path"
+)
+
+const defaultEditor = "" // There is no editor to run in the browser
+
+// UserHomeFolder is a simple cross-platform function to retrieve the users home path using environment variables.
+// Under js/wasm it is normally empty since options are stored in localStorage.
+func UserHomeFolder() string {
+	return os.Getenv("HOME")
+}
+
+// UserConfigFolder returns the folder for user specific configuration files, only used as part of localStorage keys
+func UserConfigFolder() string {
+	return path.Join("/", UserHomeFolder(), ".config")
+}
+
+// UserCacheFolder returns the folder for user specific cached data
+func UserCacheFolder() string {
+	return path.Join("/", UserHomeFolder(), ".cache")
+}
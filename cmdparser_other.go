@@ -0,0 +1,40 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !nacl && !netbsd && !openbsd && !solaris && !windows && !js
+// +build !darwin,!dragonfly,!freebsd,!linux,!nacl,!netbsd,!openbsd,!solaris,!windows,!js
+
+package cmdparser
+
+import (
+	"os"
+	"path/filepath"
+)
+
+const defaultEditor = "" // No common editor, -editoptions requires VISUAL or EDITOR to be set
+
+// UserHomeFolder is a simple cross-platform function to retrieve the users home path using environment variables.
+// Plan 9 uses $home instead of $HOME.
+func UserHomeFolder() string {
+	if path := os.Getenv("HOME"); path != "" {
+		return path
+	}
+	return os.Getenv("home")
+}
+
+// UserConfigFolder returns the folder for user specific configuration files, $XDG_CONFIG_HOME or ~/.config
+func UserConfigFolder() string {
+	if path := os.Getenv("XDG_CONFIG_HOME"); path != "" {
+		return path
+	}
+	return filepath.Join(UserHomeFolder(), ".config")
+}
+
+// UserCacheFolder returns the folder for user specific cached data, $XDG_CACHE_HOME or ~/.cache
+func UserCacheFolder() string {
+	if path := os.Getenv("XDG_CACHE_HOME"); path != "" {
+		return path
+	}
+	return filepath.Join(UserHomeFolder(), ".cache")
+}
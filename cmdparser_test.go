@@ -0,0 +1,573 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testParse parses args as the commandline of a program named test, with help and messages discarded
+func testParse(args ...string) error {
+	SetOutput(ioutil.Discard)
+	_, err := parse(append([]string{"test"}, args...), parseCommandLine)
+	return err
+}
+
+func TestNegativeNumbers(t *testing.T) {
+	tests := []struct {
+		args  []string
+		int   int64
+		float float64
+	}{
+		{[]string{"-offset", "-5"}, -5, 0},
+		{[]string{"-offset=-5"}, -5, 0},
+		{[]string{"-offset", "-0x10"}, -16, 0},
+		{[]string{"-offset", "-010"}, -8, 0},
+		{[]string{"-scale", "-1e3"}, 0, -1000},
+		{[]string{"-scale", "-0.5"}, 0, -0.5},
+		{[]string{"-scale", "-7", "-offset", "-3"}, -3, -7},
+	}
+	for _, test := range tests {
+		Reset()
+		var offset int64
+		var scale float64
+		Command("run", "", func() {})
+		IntOption("offset", "", "<n>", "Offset", &offset, 0)
+		FloatOption("scale", "", "<f>", "Scale", &scale, 0)
+		if err := testParse(append(test.args, "run")...); err != nil {
+			t.Errorf("%q: %s", test.args, err)
+		} else if offset != test.int || scale != test.float {
+			t.Errorf("%q: got offset %d and scale %v, expected %d and %v", test.args, offset, scale, test.int, test.float)
+		}
+	}
+}
+
+func TestOptionAfterValueOption(t *testing.T) {
+	Reset()
+	var name string
+	Command("run", "", func() {})
+	StringOption("name", "", "<name>", "Name", &name, 0)
+	if err := testParse("-name", "-literal", "run"); err == nil {
+		t.Errorf("-name -literal was accepted with name %q", name)
+	}
+	if err := testParse("-name=-literal", "run"); err != nil || name != "-literal" {
+		t.Errorf("-name=-literal: got %q (%v)", name, err)
+	}
+}
+
+func TestValuesWithEquals(t *testing.T) {
+	tests := []struct {
+		arg      string
+		expected string
+	}{
+		{"-value=a=b", "a=b"},
+		{"-value==", "="},
+		{"-value=key=value=more", "key=value=more"},
+		{"-value=c2VjcmV0==", "c2VjcmV0=="},
+		{"-value=https://example.com/path?a=1&b=2", "https://example.com/path?a=1&b=2"},
+	}
+	for _, test := range tests {
+		Reset()
+		var value string
+		Command("run", "", func() {})
+		StringOption("value", "", "<value>", "Value", &value, 0)
+		if err := testParse(test.arg, "run"); err != nil {
+			t.Errorf("%s: %s", test.arg, err)
+		} else if value != test.expected {
+			t.Errorf("%s: got %q, expected %q", test.arg, value, test.expected)
+		}
+	}
+}
+
+func TestByteOptionPadding(t *testing.T) {
+	Reset()
+	var key []byte
+	Command("run", "", func() {})
+	o := ByteOption("key", "", "<base64>", "Key", &key, 0)
+	for _, value := range []string{"AAAAAA==", "AAAAAAA=", "AA==", "AAAA"} {
+		if err := testParse("-key="+value, "run"); err != nil {
+			t.Errorf("-key=%s: %s", value, err)
+		} else if o.Value.String() != value {
+			t.Errorf("-key=%s: saved as %s", value, o.Value.String())
+		}
+	}
+	// AAAA is complete without padding, the extra padding must reach the decoder and be rejected
+	if err := testParse("-key=AAAA==", "run"); err == nil || !strings.Contains(err.Error(), `"AAAA=="`) {
+		t.Errorf("-key=AAAA==: expected invalid value error for the whole value, got %v", err)
+	}
+}
+
+func TestOptionIndex(t *testing.T) {
+	Reset()
+	var global, local, inherited, child bool
+	remote := Command("remote", "", nil)
+	remote.SubCommand("push", "", func() {})
+	Command("pull", "", func() {})
+	BoolOption("verbose", "", "Verbose", &global, 0)
+	BoolOption("verbose", "pull", "Verbose pull", &local, 0)
+	BoolOption("dry-run", "remote", "Dry run", &inherited, 0).Inherit()
+	BoolOption("force", "remote push", "Force", &child, 0)
+
+	if o := findScopedOption("verbose", nil); o == nil || o.Group != "" {
+		t.Errorf("verbose without scope found %v", o)
+	}
+	if o := findScopedOption("verbose", findCommand("pull")); o == nil || o.Group != "pull" {
+		t.Errorf("verbose in pull found %v", o)
+	}
+	if o := findScopedOption("dry-run", findCommand("remote push")); o == nil || o.Group != "remote" {
+		t.Errorf("inherited dry-run in remote push found %v", o)
+	}
+	if o := findRegisteredOption("pull.verbose"); o == nil || o.Group != "pull" {
+		t.Errorf("key pull.verbose found %v", o)
+	}
+	if o := findRegisteredOption("verbose"); o == nil || o.Group != "" {
+		t.Errorf("key verbose found %v", o)
+	}
+
+	SetNameNormalizer(func(name string) string {
+		return strings.Replace(strings.ToLower(name), "_", "-", -1)
+	})
+	if o := findScopedOption("DRY_RUN", findCommand("remote push")); o == nil || o.Name != "dry-run" {
+		t.Errorf("normalized DRY_RUN found %v", o)
+	}
+	if err := testParse("pull", "-VERBOSE"); err != nil || !local || global {
+		t.Errorf("pull -VERBOSE set global %v and local %v (%v)", global, local, err)
+	}
+
+	var late string
+	StringOption("late", "", "", "Added after the index was built", &late, 0)
+	if o := findOption("late"); o == nil {
+		t.Error("option added after the index was built was not found")
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	Reset()
+	values := make([]int64, 600)
+	var args []string
+	for i := range values {
+		name := fmt.Sprintf("option%d", i)
+		IntOption(name, "", "<n>", "Benchmark option", &values[i], 0)
+		if i%10 == 0 {
+			args = append(args, fmt.Sprintf("-%s=%d", name, i))
+		}
+	}
+	Command("run", "", func() {})
+	args = append(args, "run")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := testParse(args...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestParseArgsRestoresValues(t *testing.T) {
+	Reset()
+	SetOutput(ioutil.Discard)
+	var size int64
+	var byteSize int64
+	var args []string
+	CommandContext("run", "", func(ctx context.Context) {
+		args = ResultFromContext(ctx).Args
+		if size != 4 || byteSize != 4096 {
+			t.Errorf("command got size %d and bytes %d", size, byteSize)
+		}
+	})
+	IntOption("size", "", "<kb>", "Size", &size, 0).OnChange(func() {
+		byteSize = size * 1024
+	})
+	size, byteSize = 1, 1024
+	if _, err := ParseArgs([]string{"-size=4", "run", "a", "b"}); err != nil {
+		t.Fatal(err)
+	}
+	if size != 1 || byteSize != 1024 {
+		t.Errorf("after ParseArgs got size %d and bytes %d, expected 1 and 1024", size, byteSize)
+	}
+	if len(args) != 2 || args[0] != "a" || args[1] != "b" {
+		t.Errorf("ResultFromContext got args %q", args)
+	}
+	if len(Args) != 0 {
+		t.Errorf("Args of ParseArgs was kept: %q", Args)
+	}
+}
+
+func TestParseArgsConcurrent(t *testing.T) {
+	Reset()
+	SetOutput(ioutil.Discard)
+	var value int64
+	var mismatches int32
+	CommandContext("run", "", func(ctx context.Context) {
+		expected := ResultFromContext(ctx).Args[0]
+		time.Sleep(time.Millisecond)
+		if fmt.Sprint(value) != expected {
+			atomic.AddInt32(&mismatches, 1)
+		}
+	})
+	IntOption("value", "", "<n>", "Value", &value, 0)
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if _, err := ParseArgs([]string{fmt.Sprintf("-value=%d", i), "run", fmt.Sprint(i)}); err != nil {
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if mismatches > 0 {
+		t.Errorf("%d commands saw the values of another call", mismatches)
+	}
+	if value != 0 {
+		t.Errorf("value is %d after all calls returned", value)
+	}
+}
+
+// serveTest sends line to a connection handled by ServeCommands and returns everything the server sent back
+func serveTest(t *testing.T, l net.Listener, line string) string {
+	conn, err := net.Dial(l.Addr().Network(), l.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprintln(conn, line)
+	conn.(*net.TCPConn).CloseWrite()
+	reply, _ := ioutil.ReadAll(conn)
+	return string(reply)
+}
+
+func TestServeCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmdparser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	secret := filepath.Join(dir, "secret")
+	ioutil.WriteFile(secret, []byte("server file"), 0600)
+
+	Reset()
+	var local bytes.Buffer
+	SetOutput(&local)
+	OptionsFile = filepath.Join(dir, "options.json")
+	AllowFileValues = true
+	AllowResponseFiles = true
+	var name string
+	CommandContext("greet", "", func(ctx context.Context) {
+		fmt.Fprintf(ResultFromContext(ctx).Output, "hello %s\n", name)
+	})
+	Command("boom", "", func() { panic("boom") })
+	StringOption("name", "", "<name>", "Name", &name, Preference)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go ServeCommands(l)
+
+	tests := []struct {
+		line     string
+		expected string
+	}{
+		{"-name=world greet", "hello world\n"},
+		{"-name=@" + secret + " greet", "hello @" + secret + "\n"},
+		{"@" + secret, "Error: @" + secret + " is not a valid command\n"},
+		{"-saveoptions -name=x greet", "Error: Invalid option -saveoptions\n"},
+		{"-editoptions", "Error: Invalid option -editoptions\n"},
+		{"config set name x", "Error: config is not a valid command\n"},
+		{"boom", "Error: boom\n"},
+		{"greet", "hello \n"},
+	}
+	for _, test := range tests {
+		if reply := serveTest(t, l, test.line); reply != test.expected {
+			t.Errorf("%s: got %q, expected %q", test.line, reply, test.expected)
+		}
+	}
+	if _, err := os.Stat(OptionsFile); !os.IsNotExist(err) {
+		t.Errorf("options file was written by a client (%v)", err)
+	}
+	if local.Len() > 0 {
+		t.Errorf("client output was written to Output(): %q", local.String())
+	}
+}
+
+func TestByteOptionFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmdparser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keyFile := filepath.Join(dir, "key.bin")
+	ioutil.WriteFile(keyFile, []byte{0, 1, 2, 255}, 0600)
+	optionsFile := filepath.Join(dir, "options.json")
+	ioutil.WriteFile(optionsFile, []byte(`{"key": "file://`+keyFile+`"}`), 0600)
+
+	tests := []struct {
+		name     string
+		encoding ByteEncoding
+		parse    func() error
+		loaded   bool // Value is expected to be the content of the file, otherwise an error is expected
+	}{
+		{"commandline", Base64, func() error { return testParse("-key=file://"+keyFile, "run") }, true},
+		{"commandline hex", Hex, func() error { return testParse("-key", "file://"+keyFile, "run") }, true},
+		{"missing file", Base64, func() error { return testParse("-key=file://"+keyFile+".missing", "run") }, false},
+		{"Set", Base64, func() error { return Set("key", "file://"+keyFile) }, false},
+		{"options file", Base64, func() error { OptionsFile = optionsFile; return testParse("run") }, false},
+		{"remote", Base64, func() error {
+			_, err := parse([]string{"test", "-key=file://" + keyFile, "run"}, parseRemote)
+			return err
+		}, false},
+	}
+	for _, test := range tests {
+		Reset()
+		var key []byte
+		Command("run", "", func() {})
+		o := ByteOption("key", "", "", "Key", &key, Preference)
+		if test.encoding != Base64 {
+			o.Encoding(test.encoding)
+		}
+		err := test.parse()
+		if test.loaded && (err != nil || !bytes.Equal(key, []byte{0, 1, 2, 255})) {
+			t.Errorf("%s: got %v (%v)", test.name, key, err)
+		} else if !test.loaded && err == nil {
+			t.Errorf("%s: file was read, got %v", test.name, key)
+		}
+	}
+}
+
+func TestSecretAccess(t *testing.T) {
+	Reset()
+	SetOutput(ioutil.Discard)
+	var password Secret
+	Command("run", "", func() {})
+	SecretOption("password", "", "<password>", "Password", &password, 0)
+	if err := testParse("-password=hunter2", "run"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		read     func() string
+		recorded bool // Read reveals the value and is recorded, otherwise it must not reveal the value
+	}{
+		{"Value", password.Value, true},
+		{"Sprint", func() string { return fmt.Sprint(password, &password) }, false},
+		{"Value.String", func() string { return Lookup("password").Value.String() }, false},
+		{"Get", func() string { return fmt.Sprint(Lookup("password").Value.Get()) }, false},
+		{"Snapshot", func() string { Restore(Snapshot()); return "" }, false},
+		{"WithOverrides", func() string { WithOverrides(map[string]string{"password": "other"}, func() {}); return "" }, false},
+		{"ParseArgs", func() string { ParseArgs([]string{"run"}); return "" }, false},
+		{"Options", func() string { return fmt.Sprint(Options()) }, false},
+		{"ExportEnv", func() string { return fmt.Sprint(ExportEnv("TEST_")) }, false},
+		{"GetString", func() string { s, _ := Lookup("password").GetString(); return s }, false},
+	}
+	for _, test := range tests {
+		before := len(SecretAccesses())
+		value := test.read()
+		accesses := SecretAccesses()[before:]
+		if test.recorded {
+			if value != "hunter2" || len(accesses) != 1 || accesses[0].Option != "password" {
+				t.Errorf("%s: got %q with accesses %v", test.name, value, accesses)
+			}
+		} else if strings.Contains(value, "hunter2") || len(accesses) > 0 {
+			t.Errorf("%s: got %q with accesses %v", test.name, value, accesses)
+		}
+	}
+	if password.Value() != "hunter2" {
+		t.Errorf("secret changed to %q", password.Value())
+	}
+}
+
+func TestParseKnown(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cmdparser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	nameFile := filepath.Join(dir, "name")
+	ioutil.WriteFile(nameFile, []byte("from file\n"), 0600)
+
+	tests := []struct {
+		args    []string
+		unknown []string
+		name    string
+		tags    []string
+		local   bool
+		err     bool
+	}{
+		{[]string{"-name=a", "--rm", "image", "-x", "1"}, []string{"--rm", "image", "-x", "1"}, "a", nil, false, false},
+		{[]string{"-tags", "a", "-tags+=b", "-tags-=a"}, nil, "", []string{"b"}, false, false},
+		{[]string{"-name=a", "-name=b"}, nil, "", nil, false, true},
+		{[]string{"-name=@" + nameFile}, nil, "from file", nil, false, false},
+		{[]string{"-elsewhere"}, nil, "", nil, false, true},
+		{[]string{"run", "-local"}, []string{"run"}, "", nil, true, false},
+		{[]string{"-local", "run"}, []string{"run"}, "", nil, true, false},
+		{[]string{"-h", "-saveoptions", "--", "-name=a"}, []string{"-h", "-saveoptions", "--", "-name=a"}, "", nil, false, false},
+	}
+	for _, test := range tests {
+		// ParseKnown and Parse must set the same options and accept and reject the same input
+		for _, known := range []bool{true, false} {
+			Reset()
+			AllowFileValues = true
+			var name string
+			var tags []string
+			var local, elsewhere bool
+			Command("run", "", func() {}).OnUnknownOption(func(string, string) error { return nil })
+			StringOption("name", "", "<name>", "Name", &name, 0).Duplicates(DuplicateError)
+			StringListOption("tags", "", "<tag>", "Tags", &tags, 0)
+			BoolOption("local", "run", "Local", &local, 0)
+			BoolOption("elsewhere", "", "Elsewhere", &elsewhere, 0).Platforms("nosuchos")
+
+			var unknown []string
+			var err error
+			if known {
+				unknown, err = ParseKnown(test.args)
+			} else if len(test.unknown) > 0 {
+				continue
+			} else {
+				err = testParse(append([]string{"run"}, test.args...)...)
+			}
+			if (err != nil) != test.err {
+				t.Errorf("%q (known %v): got error %v", test.args, known, err)
+			} else if err == nil && (name != test.name || fmt.Sprint(tags) != fmt.Sprint(test.tags) || local != test.local) {
+				t.Errorf("%q (known %v): got name %q, tags %q and local %v", test.args, known, name, tags, local)
+			} else if known && fmt.Sprint(unknown) != fmt.Sprint(test.unknown) {
+				t.Errorf("%q: got unknown %q, expected %q", test.args, unknown, test.unknown)
+			}
+		}
+	}
+}
+
+func TestPluginDispatch(t *testing.T) {
+	if runtime.GOOS == "windows" || runtime.GOOS == "js" {
+		t.Skip("plugin test uses a shell script")
+	}
+	dir, err := ioutil.TempDir("", "cmdparser")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	script := "#!/bin/sh\necho \"args: $*\"\necho \"env: $TEST_NAME $TEST_TOKEN\"\necho stderr >&2\n[ \"$1\" = fail ] && exit 3\nexit 0\n"
+	ioutil.WriteFile(filepath.Join(dir, "test-greet"), []byte(script), 0755)
+	defer os.Setenv("PATH", os.Getenv("PATH"))
+	os.Setenv("PATH", dir)
+
+	const test3Output = "args: fail\nenv:  \nstderr\n"
+	tests := []struct {
+		args     []string
+		expected string
+		code     int
+	}{
+		{[]string{"-name=x", "greet", "-loud", "a"}, "args: -loud a\nenv: x \nstderr\n", 0},
+		{[]string{"greet", "b"}, "args: b\nenv:  \nstderr\n", 0},
+		{[]string{"-token=secret", "greet", "fail"}, test3Output, 3},
+	}
+	Reset()
+	var name, token string
+	StringOption("name", "", "<name>", "Name", &name, 0)
+	StringOption("token", "", "<token>", "Token", &token, Sensitive)
+	plugins := DiscoverPlugins("test")
+	if len(plugins) != 1 || plugins[0].Command != "greet" || plugins[0].Function != nil {
+		t.Fatalf("got plugins %v", plugins)
+	}
+	for _, test := range tests {
+		var output bytes.Buffer
+		_, err := parseIsolated(test.args, &output, parseCommandLine)
+		code := 0
+		if e, ok := err.(*PluginExitError); ok {
+			code = e.Code
+		} else if err != nil {
+			t.Errorf("%q: %v", test.args, err)
+		}
+		if output.String() != test.expected || code != test.code {
+			t.Errorf("%q: got %q with code %d, expected %q with code %d", test.args, output.String(), code, test.expected, test.code)
+		}
+	}
+	var output bytes.Buffer
+	SetOutput(&output)
+	Args = []string{"fail"}
+	if err, ok := plugins[0].Run().(*PluginExitError); !ok || err.Code != 3 || output.String() != test3Output {
+		t.Errorf("Run returned %v with %q", err, output.String())
+	}
+}
+
+func TestOptionPrefixMessages(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"--missing"}, "Invalid option --missing"},
+		{[]string{"--count=1", "--count=2"}, "Option --count can only be specified once"},
+		{[]string{"--except=count"}, "--except can only be used together with --saveoptions"},
+	}
+	for _, test := range tests {
+		Reset()
+		SetOptionPrefixes("--")
+		var count int64
+		IntOption("count", "", "<n>", "Count", &count, Standard).Duplicates(DuplicateError)
+		OptionsFile = filepath.Join(os.TempDir(), "cmdparser-missing.json")
+		if err := testParse(test.args...); err == nil || err.Error() != test.expected {
+			t.Errorf("%q: got %v, expected %s", test.args, err, test.expected)
+		}
+	}
+	Reset()
+}
+
+func TestRangeLists(t *testing.T) {
+	tests := []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"-int=5", "-ints=1", "-ints=10", "-floats=0.5"}, ""},
+		{[]string{"-int=11"}, "value must be at most 10"},
+		{[]string{"-ints=5", "-ints=11"}, "value must be at most 10"},
+		{[]string{"-ints=0"}, "value must be at least 1"},
+		{[]string{"-floats=0.5", "-floats=1.5"}, "value must be at most 1"},
+		{[]string{"-floats=-0.1"}, "value must be at least 0"},
+	}
+	for _, test := range tests {
+		Reset()
+		var i int64
+		var ints []int64
+		var floats []float64
+		IntOption("int", "", "<n>", "Int", &i, Standard).Min(1).Max(10)
+		IntListOption("ints", "", "<n>", "Ints", &ints, Standard).Min(1).Max(10)
+		FloatListOption("floats", "", "<f>", "Floats", &floats, Standard).Min(0).Max(1)
+		Command("run", "", func() {})
+		err := testParse(append(test.args, "run")...)
+		if (err == nil) != (test.expected == "") || err != nil && !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("%q: got %v, expected %q", test.args, err, test.expected)
+		}
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"aaaa bbbb cccc dddd eeee ffff", "  aaaa bbbb cccc dddd\n  eeee ffff"},
+		{"åååå ääää öööö üüüü éééé", "  åååå ääää öööö üüüü\n  éééé"},
+		{"one\ntwo", "  one\n  two"},
+	}
+	for _, test := range tests {
+		if got := wrapText(test.text, 2, 23); got != test.expected {
+			t.Errorf("%q: got %q, expected %q", test.text, got, test.expected)
+		}
+	}
+}
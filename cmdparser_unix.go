@@ -2,13 +2,35 @@
 // Use of this source code is governed by the standard MIT License (MIT)
 // that can be found in the LICENSE file.
 
-// +build darwin dragonfly freebsd linux nacl netbsd openbsd solaris
+//go:build dragonfly || freebsd || linux || nacl || netbsd || openbsd || solaris
+// +build dragonfly freebsd linux nacl netbsd openbsd solaris
 
 package cmdparser
 
-import "os"
+import (
+	"os"
+	"path/filepath"
+)
+
+const defaultEditor = "vi" // Editor used by -editoptions when neither VISUAL nor EDITOR is set
 
 // UserHomeFolder is a simple cr0ss-platform function to retrieve the users home path using environment variables.
 func UserHomeFolder() string {
 	return os.Getenv("HOME")
 }
+
+// UserConfigFolder returns the folder for user specific configuration files, $XDG_CONFIG_HOME or ~/.config
+func UserConfigFolder() string {
+	if path := os.Getenv("XDG_CONFIG_HOME"); path != "" {
+		return path
+	}
+	return filepath.Join(UserHomeFolder(), ".config")
+}
+
+// UserCacheFolder returns the folder for user specific cached data, $XDG_CACHE_HOME or ~/.cache
+func UserCacheFolder() string {
+	if path := os.Getenv("XDG_CACHE_HOME"); path != "" {
+		return path
+	}
+	return filepath.Join(UserHomeFolder(), ".cache")
+}
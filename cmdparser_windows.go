@@ -2,19 +2,39 @@
 // Use of this source code is governed by the standard MIT License (MIT)
 // that can be found in the LICENSE file.
 
+//go:build windows
 // +build windows
 
 package cmdparser
 
-import "os"
+import (
+	"os"
+	"path/filepath"
+)
+
+const defaultEditor = "notepad" // Editor used by -editoptions when neither VISUAL nor EDITOR is set
 
 // UserHomeFolder is a simple cross-platform function to retrieve the users home path using environment variables.
+// USERPROFILE is preferred since HOMEDRIVE and HOMEPATH can point to a network share.
 func UserHomeFolder() string {
-	drive := os.Getenv("HOMEDRIVE")
-	path := os.Getenv("HOMEPATH")
-	if drive == "" || path == "" {
-		return os.Getenv("USERPROFILE")
-	} else {
-		return drive + path
+	if path := os.Getenv("USERPROFILE"); path != "" {
+		return path
+	}
+	return os.Getenv("HOMEDRIVE") + os.Getenv("HOMEPATH")
+}
+
+// UserConfigFolder returns the folder for user specific configuration files, %APPDATA%
+func UserConfigFolder() string {
+	if path := os.Getenv("APPDATA"); path != "" {
+		return path
+	}
+	return filepath.Join(UserHomeFolder(), "AppData", "Roaming")
+}
+
+// UserCacheFolder returns the folder for user specific cached data, %LOCALAPPDATA%
+func UserCacheFolder() string {
+	if path := os.Getenv("LOCALAPPDATA"); path != "" {
+		return path
 	}
+	return filepath.Join(UserHomeFolder(), "AppData", "Local")
 }
@@ -0,0 +1,68 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+// Package cmdparsertest provides helpers for testing applications built with cmdparser. Arguments are passed
+// with cmdparser.SetArgSource and output is captured with cmdparser.SetOutput, so os.Args and os.Stdout are left
+// untouched. Commands that should have their output captured write it to cmdparser.Output().
+//
+//	func TestAdd(t *testing.T) {
+//		r := cmdparsertest.Run(t, []string{"add", "-count=2"}, setup)
+//		if r.Err != nil || r.Command != "add" {
+//			t.Fatalf("unexpected result %v: %s", r.Err, r.Output)
+//		}
+//	}
+package cmdparsertest
+
+import (
+	"bytes"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/fredli74/cmdparser"
+)
+
+// Result holds the outcome of a single Run
+type Result struct {
+	Command string   // Name of the command that was resolved, empty if no command was found
+	Args    []string // Arguments that were not parsed, not including the command name
+	Output  string   // Everything printed by the parser and to cmdparser.Output() while parsing and dispatching
+	Err     error    // Error returned by the parser
+
+	Parsed *cmdparser.ParseResult // Full parse result including where each option value came from
+}
+
+var runMutex sync.Mutex // Serializes runs since the parser is global
+
+// Run resets the parser, calls setup to add commands and options, then parses and dispatches args (not including
+// the program name). Output from Usage, the built-in flags and anything the command writes to cmdparser.Output() is
+// captured in the Result. Option variables keep their parsed values so they can be checked after Run returns, the
+// parser is reset again when the test finishes.
+func Run(t testing.TB, args []string, setup func()) *Result {
+	t.Helper()
+	runMutex.Lock()
+	defer runMutex.Unlock()
+
+	cmdparser.Reset()
+	t.Cleanup(cmdparser.Reset)
+	if setup != nil {
+		setup()
+	}
+
+	var buf bytes.Buffer
+	cmdparser.SetOutput(&buf)
+	commandline := append([]string{os.Args[0]}, args...)
+	cmdparser.SetArgSource(cmdparser.ArgSourceFunc(func() []string { return commandline }))
+
+	result := &Result{}
+	result.Parsed, result.Err = cmdparser.ParseWithResult()
+	result.Output = buf.String()
+	if result.Parsed != nil {
+		if result.Parsed.Command != nil {
+			result.Command = result.Parsed.Command.Command
+		}
+		result.Args = result.Parsed.Args
+	}
+	return result
+}
@@ -0,0 +1,53 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparsertest
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fredli74/cmdparser"
+)
+
+func TestRun(t *testing.T) {
+	osArgs, stdout := os.Args, os.Stdout
+	var count int64
+	var name string
+	setup := func() {
+		cmdparser.Command("greet", "<name>", func() {
+			fmt.Fprintf(cmdparser.Output(), "hello %s x%d\n", name, count)
+		})
+		cmdparser.IntOption("count", "greet", "<n>", "Number of greetings", &count, 0)
+		cmdparser.StringOption("name", "greet", "<name>", "Who to greet", &name, 0)
+	}
+
+	r := Run(t, []string{"greet", "-count=2", "-name=world", "extra"}, setup)
+	if r.Err != nil {
+		t.Fatalf("unexpected error %v", r.Err)
+	}
+	if r.Command != "greet" || !reflect.DeepEqual(r.Args, []string{"extra"}) {
+		t.Errorf("got command %q args %q", r.Command, r.Args)
+	}
+	if r.Output != "hello world x2\n" {
+		t.Errorf("got output %q", r.Output)
+	}
+	if count != 2 || name != "world" {
+		t.Errorf("option values not kept, got %d and %q", count, name)
+	}
+	if !reflect.DeepEqual(os.Args, osArgs) || os.Stdout != stdout {
+		t.Error("os.Args or os.Stdout was changed")
+	}
+
+	r = Run(t, []string{"nosuchcommand"}, setup)
+	if r.Err == nil || r.Command != "" {
+		t.Fatalf("expected an error for an unknown command, got %v and %q", r.Err, r.Command)
+	}
+	if !strings.Contains(r.Err.Error(), "nosuchcommand") {
+		t.Errorf("error does not name the command: %v", r.Err)
+	}
+}
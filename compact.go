@@ -0,0 +1,108 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+var compactErrors bool
+
+// SetCompactErrors controls what is printed when the command is missing or invalid, or a required option is missing.
+// By default the full help is printed for a missing command. With SetCompactErrors(true) a single usage line for the
+// command that was most likely intended is printed instead, with a suggestion for a mistyped command, which keeps the
+// output of scripts readable.
+//
+//	cmdparse.SetCompactErrors(true)
+//
+//	mytool psuh
+//	Did you mean push?
+//	Usage: mytool [options] push <path>
+func SetCompactErrors(compact bool) {
+	compactErrors = compact
+}
+
+// usageLine returns the usage of a command on a single line, without the heading
+func usageLine(c *CmdCommand) string {
+	if c.usage != "" {
+		return commandName + " " + c.usage
+	}
+	line := commandName + " [options] " + c.Command
+	if c.Help != "" {
+		line += " " + c.Help
+	}
+	return line
+}
+
+// compactUsage prints a single usage line for focus, or for the command most likely intended by args if focus is nil
+func compactUsage(focus *CmdCommand, args []string) {
+	if focus == nil && len(args) > 0 {
+		if focus = likelyCommand(args); focus != nil {
+			fmt.Fprintf(output, messages.DidYouMean+"\n", focus.Command)
+		}
+	}
+	line := commandName + " [options] <command>"
+	if focus != nil && !focus.runnable() && focus.hasChildren() {
+		var children []string
+		for _, c := range sortedCommands() {
+			if c.parent == focus {
+				children = append(children, c.Command[len(focus.Command)+1:])
+			}
+		}
+		line = commandName + " [options] " + focus.Command + " <" + strings.Join(children, "|") + ">"
+	} else if focus != nil {
+		line = usageLine(focus)
+	}
+	fmt.Fprintf(output, messages.CompactUsage+"\n", line)
+	if len(helpFlags) > 0 {
+		fmt.Fprintf(output, messages.HelpHint+"\n", helpFlags[0])
+	}
+}
+
+// likelyCommand returns the command closest to the start of args, or nil if no command is close enough to be a typo
+func likelyCommand(args []string) *CmdCommand {
+	var best *CmdCommand
+	bestDistance := 0
+	for _, c := range commandList {
+		words := strings.Fields(c.Command)
+		if len(words) == 0 || len(words) > len(args) {
+			continue
+		}
+		d := editDistance(strings.Join(args[:len(words)], " "), c.Command)
+		if d <= len(c.Command)/2 && (best == nil || d < bestDistance) {
+			best, bestDistance = c, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	row := make([]int, len(rb)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		diagonal := row[0]
+		row[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			next := diagonal + cost
+			if row[j]+1 < next {
+				next = row[j] + 1
+			}
+			if row[j-1]+1 < next {
+				next = row[j-1] + 1
+			}
+			diagonal, row[j] = row[j], next
+		}
+	}
+	return row[len(rb)]
+}
@@ -0,0 +1,144 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// completeCommand is the hidden command called by shell completion scripts
+const completeCommand = "__complete"
+
+// completeFunc returns completion candidates for an option value
+type completeFunc func(prefix string) []string
+
+// CompleteWith sets a function that returns the values the option can be completed with, given the part of the
+// value typed so far. It is used by the hidden __complete command that shell completion scripts call, making it
+// possible to complete values like remote names or profiles that are only known at runtime. Options without a
+// completion function are completed with their value aliases and bool options with true and false. Recent values
+// of MRU options are offered as well.
+//
+//	cmdparse.StringOption("remote", "", "<name>", "Remote to use", &remote, cmdparse.Standard).
+//	  CompleteWith(func(prefix string) []string {
+//	    return listRemotes(prefix)
+//	  })
+//
+// The completion protocol is
+//
+//	mytool __complete [arguments...] <word>
+//
+// where word is the word being completed, blank for a new word. Every candidate is printed on a line of its own.
+func (c *CmdOption) CompleteWith(f func(prefix string) []string) *CmdOption {
+	c.completer = f
+	return c
+}
+
+// completions returns the values the option can be completed with
+func (c *CmdOption) completions(prefix string) []string {
+	var values []string
+	if c.completer != nil {
+		values = c.completer(prefix)
+	} else if _, ok := c.Value.(*boolOption); ok {
+		values = []string{"true", "false"}
+	} else {
+		for _, a := range c.aliases {
+			values = append(values, a[0])
+		}
+	}
+	for _, r := range c.recent {
+		if !contains(values, r) {
+			values = append(values, r)
+		}
+	}
+	var list []string
+	for _, v := range values {
+		if strings.HasPrefix(v, prefix) {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// complete prints the completion candidates for the last of args, see CompleteWith
+func complete(args []string) {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	word, args := args[len(args)-1], args[:len(args)-1]
+
+	var positional []string
+	var pending *CmdOption // Option waiting for its value in the following argument
+	for _, a := range args {
+		if pending != nil && isValueArg(pending, a) {
+			pending = nil
+			continue
+		}
+		pending = nil
+		if name, ok := optionName(a); ok {
+			if o := findScopedOption(name, matchCommand(positional)); o != nil && !strings.Contains(name, "=") && takesValue(o) {
+				pending = o
+			}
+		} else {
+			positional = append(positional, a)
+		}
+	}
+	scope := matchCommand(positional)
+
+	var candidates []string
+	if pending != nil {
+		candidates = pending.completions(word)
+	} else if name, ok := optionName(word); ok && strings.Contains(name, "=") {
+		pair := strings.SplitN(name, "=", 2)
+		if o := findScopedOption(pair[0], scope); o != nil {
+			for _, v := range o.completions(pair[1]) {
+				candidates = append(candidates, word[:len(word)-len(pair[1])]+v)
+			}
+		}
+	} else if strings.HasPrefix(word, displayPrefix) && word != "" {
+		seen := make(map[string]bool)
+		for _, o := range optionList {
+			if o.Flags&Hidden == 0 && !seen[o.Name] && strings.HasPrefix(displayPrefix+o.Name, word) && findScopedOption(o.Name, scope) == o {
+				seen[o.Name] = true
+				candidates = append(candidates, displayPrefix+o.Name)
+			}
+		}
+	} else {
+		for _, c := range commandList {
+			words := strings.Fields(c.Command)
+			if !c.available() || len(words) <= len(positional) || !strings.HasPrefix(words[len(positional)], word) {
+				continue
+			}
+			match := true
+			for i, p := range positional {
+				match = match && words[i] == p
+			}
+			if match && !contains(candidates, words[len(positional)]) {
+				candidates = append(candidates, words[len(positional)])
+			}
+		}
+	}
+	for _, c := range candidates {
+		fmt.Fprintln(output, c)
+	}
+}
+
+// takesValue returns true if the option takes its value from the following argument when specified without =
+func takesValue(o *CmdOption) bool {
+	switch o.Value.(type) {
+	case *boolOption, boolPtrOption, disabledOption, flagBoolValue:
+		return false
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+const configCommand = "config" // Built-in command for Preference options, available when OptionsFile is set
+
+// hasConfigCommand returns true if the built-in config command is available
+func hasConfigCommand() bool {
+	return OptionsFile != "" && findCommand(configCommand) == nil
+}
+
+// runConfig runs the built-in config command, which reads and changes Preference options in the options file
+//
+//	mytool config list
+//	mytool config get <name>
+//	mytool config set <name> <value> [<value>...]
+//	mytool config unset <name>
+func runConfig(args []string) error {
+	if len(args) == 0 {
+		return errors.New(messages.MissingConfigCommand)
+	}
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		listPreferences()
+		return nil
+	case args[0] == "get" && len(args) == 2:
+		o, err := findPreference(args[1], false)
+		if err != nil {
+			return err
+		}
+		value := o.Value.String()
+		if o.masked() {
+			value = maskedValue
+		}
+		fmt.Fprintln(output, value)
+		return nil
+	case args[0] == "set" && len(args) >= 3:
+		o, err := findPreference(args[1], true)
+		if err != nil {
+			return err
+		} else if len(args) > 3 && !o.accumulates() {
+			return fmt.Errorf(messages.ConfigSingleValue, args[1])
+		}
+		if o.accumulates() {
+			o.Value.Reset()
+		}
+		for _, v := range args[2:] {
+			if err := o.apply(v, SourceCommandLine); err != nil {
+				return o.invalidValue(args[1], v, err)
+			}
+		}
+	case args[0] == "unset" && len(args) == 2:
+		o, err := findPreference(args[1], true)
+		if err != nil {
+			return err
+		}
+		o.setString(o.Default)
+		o.source = SourceDefault
+		emitEvent(OptionReset, o)
+		o.doChange()
+	case args[0] == "get" || args[0] == "set" || args[0] == "unset" || args[0] == "list":
+		return fmt.Errorf(messages.ConfigArguments, args[0])
+	default:
+		return fmt.Errorf(messages.UnknownConfigCommand, args[0])
+	}
+	if _, err := saveOptions(OptionsFile); err != nil {
+		return err
+	}
+	fmt.Fprintf(output, messages.OptionsSaved+"\n", OptionsFile)
+	return nil
+}
+
+// findPreference returns the Preference option with the options file key, changing requires the option to not be Locked
+func findPreference(key string, change bool) (*CmdOption, error) {
+	o := findRegisteredOption(key)
+	if o == nil || o.Flags&Preference == 0 {
+		return nil, fmt.Errorf(messages.NotPreference, key)
+	} else if change && o.Flags&Locked > 0 {
+		return nil, o.lockedError()
+	}
+	return o, nil
+}
+
+// listPreferences prints the value of every Preference option and where it was set from
+func listPreferences() {
+	var width int
+	for _, o := range optionList {
+		if o.Flags&Preference > 0 && len(o.key()) > width {
+			width = len(o.key())
+		}
+	}
+	for _, o := range sortedOptions() {
+		if o.Flags&Preference == 0 {
+			continue
+		}
+		value := o.Value.String()
+		if value == "" {
+			value = `""`
+		} else if o.masked() {
+			value = maskedValue
+		}
+		fmt.Fprintf(output, "%-*s = %s (%s)\n", width, o.key(), value, o.source)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import "strings"
+
+var normalizeDashes bool
+
+// unicodeDashes are the dash characters that NormalizeDashes replaces with '-'
+var unicodeDashes = strings.NewReplacer(
+	"‐", "-", // Hyphen
+	"‑", "-", // Non-breaking hyphen
+	"‒", "-", // Figure dash
+	"–", "-", // En dash
+	"—", "-", // Em dash
+	"―", "-", // Horizontal bar
+	"−", "-", // Minus sign
+	"﹣", "-", // Small hyphen-minus
+	"－", "-", // Fullwidth hyphen-minus
+)
+
+// NormalizeDashes replaces Unicode dashes at the start of arguments with '-' before parsing, so that options pasted
+// from a document where the editor turned -name into –name are still recognized, and −5 is read as a negative number.
+// Arguments following -- are left as they are, which is also the way to pass a filename that starts with a dash.
+//
+//	cmdparse.NormalizeDashes(true)
+//
+//	mytool –verbose copy a b
+func NormalizeDashes(enable bool) {
+	normalizeDashes = enable
+}
+
+// normalizeDash returns arg with the Unicode dashes at the start replaced by '-' if NormalizeDashes is enabled
+func normalizeDash(arg string) string {
+	if !normalizeDashes {
+		return arg
+	}
+	rest := strings.TrimLeft(arg, "-‐‑‒–—―−﹣－")
+	return unicodeDashes.Replace(arg[:len(arg)-len(rest)]) + rest
+}
+
+// normalizeArgs returns a copy of args with the dashes of every argument before -- normalized
+func normalizeArgs(args []string) []string {
+	if !normalizeDashes {
+		return args
+	}
+	normalized := make([]string, len(args))
+	for i, a := range args {
+		if a = normalizeDash(a); a == "--" {
+			copy(normalized[i:], args[i:])
+			break
+		}
+		normalized[i] = a
+	}
+	return normalized
+}
@@ -0,0 +1,153 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import "fmt"
+
+// LoadDefaultOptions sets the defaults of options from JSON in the same format as the options file, typically
+// embedded in the application with go:embed. This makes it possible to ship tuned defaults without writing an
+// options file on first run. The options file and commandline are applied on top of the defaults when parsing and
+// options that have their default value are not saved with -saveoptions. Call LoadDefaultOptions after adding the
+// options, unknown options are reported as warnings.
+//
+//	//go:embed defaults.json
+//	var defaults []byte
+//
+//	if err := cmdparse.LoadDefaultOptions(defaults); err != nil {
+//	  panic(err)
+//	}
+func LoadDefaultOptions(data []byte) error {
+	entries, offset, err := decodeOptions(data)
+	if err != nil {
+		return fmt.Errorf("%s: %s", filePosition("default options", data, offset), err.Error())
+	}
+	for _, e := range entries {
+		o := findRegisteredOption(e.key)
+		if o == nil {
+			warn(WarningUnknownOption, e.key, messages.UnknownOptionIgnored, fmt.Sprintf("%s: "+messages.FileUnknownOption, filePosition("default options", data, e.offset), e.key))
+			continue
+		}
+		if err := setJSONValue(o, e.value); err != nil {
+			return fmt.Errorf("%s: "+messages.FileInvalidValue, filePosition("default options", data, e.offset), o.key(), err.Error(), o.expectation())
+		}
+		o.Default = o.plainString()
+		o.source = SourceDefault
+	}
+	return nil
+}
+
+var commandDefaults = make(map[string]map[string]string) // Defaults of options by option key and command name
+var replacedDefaults = make(map[*CmdOption]string)       // Defaults replaced by command defaults in the last parse
+
+// OptionDefault sets a different default for an option when a specific command is run, so that an option shared by
+// several commands can have a default that suits each of them. The default is used when the option is not set from
+// the options file or commandline. It also applies to child commands of the command, unless they have a default of
+// their own. The option is specified by name or by its options file key, and does not have to be added yet.
+//
+//	cmdparse.StringOption("timeout", "", "<duration>", "Network timeout", &timeout, cmdparse.Preference)
+//	cmdparse.OptionDefault("timeout", "push", "60s")
+func OptionDefault(name string, cmd string, value string) {
+	parseMutex.Lock()
+	defer parseMutex.Unlock()
+
+	if commandDefaults[name] == nil {
+		commandDefaults[name] = make(map[string]string)
+	}
+	commandDefaults[name][cmd] = value
+}
+
+// commandDefault returns the default of the option for the command or its closest parent with a default
+func (c *CmdOption) commandDefault(command *CmdCommand) (string, bool) {
+	defaults := commandDefaults[c.key()]
+	if defaults == nil {
+		defaults = commandDefaults[c.Name]
+	}
+	for ; command != nil && defaults != nil; command = command.parent {
+		if d, ok := defaults[command.Command]; ok {
+			return d, true
+		}
+	}
+	return "", false
+}
+
+// applyCommandDefaults sets the command defaults of options that have not been set, once the command is known
+func applyCommandDefaults(command *CmdCommand) error {
+	for _, o := range optionList {
+		d, ok := o.commandDefault(command)
+		if !ok {
+			continue
+		}
+		if o.source == SourceDefault && o.plainString() == o.Default {
+			if err := o.setString(d); err != nil {
+				return fmt.Errorf(messages.InvalidCommandDefault, d, displayPrefix+o.Name, command.Command, err.Error())
+			}
+		}
+		replacedDefaults[o] = o.Default
+		o.Default = d
+	}
+	return nil
+}
+
+// restoreDefaults puts back the defaults replaced by command defaults in the previous parse
+func restoreDefaults() {
+	for o, d := range replacedDefaults {
+		if o.source == SourceDefault && o.plainString() == o.Default {
+			o.setString(d)
+		}
+		o.Default = d
+	}
+	replacedDefaults = make(map[*CmdOption]string)
+}
+
+// InheritValue makes a command option use the value of the option with the same name outside the command as its
+// default, which is the inherited option of a parent command or the global option. This gives a global option and a
+// command option of the same name, where the command option follows the global option unless it is set after the
+// command name. InheritValue is applied after the commandline is parsed, so the value includes the options file and
+// commandline.
+//
+//	cmdparse.BoolOption("verbose", "", "", "Verbose output", &verbose, cmdparse.Preference)
+//	cmdparse.BoolOption("verbose", "push", "", "Verbose transfer log", &pushVerbose, 0).InheritValue()
+//
+//	mytool -verbose push .            // both are true
+//	mytool push -verbose=false .      // only the global option is true
+func (c *CmdOption) InheritValue() *CmdOption {
+	c.inheritValue = true
+	return c
+}
+
+// applyInheritedValues sets the defaults of InheritValue options to the value of the outer option of the same name
+func applyInheritedValues() error {
+	for _, o := range optionList {
+		if !o.inheritValue || o.Group == "" {
+			continue
+		}
+		var scope *CmdCommand
+		if command := findCommand(o.Group); command != nil {
+			scope = command.parent
+		}
+		outer := findScopedOption(o.Name, scope)
+		if outer == nil || outer == o {
+			continue
+		}
+		outside := outer.Group == ""
+		for c := scope; c != nil && !outside; c = c.parent {
+			outside = outer.Group == c.Command
+		}
+		if !outside {
+			continue
+		}
+		d := outer.plainString()
+		if o.source == SourceDefault && o.plainString() == o.Default {
+			if err := o.setString(d); err != nil {
+				return fmt.Errorf(messages.InvalidInheritedValue, d, displayPrefix+o.Name, o.Group, err.Error())
+			}
+		}
+		if _, replaced := replacedDefaults[o]; !replaced {
+			replacedDefaults[o] = o.Default
+		}
+		o.Default = d
+	}
+	return nil
+}
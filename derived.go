@@ -0,0 +1,86 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+var derivedDeferred bool                       // OnChange hooks of derived options are deferred while parsing
+var derivedPending = make(map[*CmdOption]bool) // derived options with deferred OnChange hooks
+
+// DerivedFrom declares that the OnChange hook of the option derives a value from the option itself and the named
+// options. While parsing, the hook is not called for each change but once after all options have their final values,
+// if the option or any of the named options changed. Hooks of derived options that depend on each other are called
+// in dependency order, so the result does not depend on the order of the options on the commandline.
+//
+//	cmdparse.StringOption("user", "", "<name>", "User name", &user, cmdparse.Standard)
+//	cmdparse.StringOption("password", "", "<password>", "Password", &password, cmdparse.Standard).
+//	  DerivedFrom("user").OnChange(func() {
+//	    accesskey = GenerateAccessKey(user, password)
+//	  })
+func (c *CmdOption) DerivedFrom(names ...string) *CmdOption {
+	c.derivedFrom = append(c.derivedFrom, names...)
+	return c
+}
+
+// dependsOn returns true if the option is derived from o
+func (c *CmdOption) dependsOn(o *CmdOption) bool {
+	for _, name := range c.derivedFrom {
+		if findOption(name) == o {
+			return true
+		}
+	}
+	return false
+}
+
+// deferDerived defers the OnChange hooks of derived options until the returned function is called
+func deferDerived() func() {
+	derivedDeferred = true
+	return func() {
+		derivedDeferred = false
+		runDerived()
+	}
+}
+
+// changeDerived marks the derived options affected by a change of o, it returns true if the OnChange hook of o
+// itself is deferred
+func changeDerived(o *CmdOption) bool {
+	if !derivedDeferred {
+		return false
+	}
+	for _, d := range optionList {
+		if d.dependsOn(o) {
+			derivedPending[d] = true
+		}
+	}
+	if len(o.derivedFrom) > 0 {
+		derivedPending[o] = true
+		return true
+	}
+	return false
+}
+
+// runDerived calls the deferred OnChange hooks, each hook after the hooks of the options it is derived from
+func runDerived() {
+	for len(derivedPending) > 0 {
+		var next *CmdOption
+		for _, o := range optionList {
+			if !derivedPending[o] {
+				continue
+			}
+			ready := true
+			for p := range derivedPending {
+				ready = ready && (p == o || !o.dependsOn(p))
+			}
+			if ready || next == nil {
+				next = o // Options in a dependency cycle are called in the order they were added
+			}
+			if ready {
+				break
+			}
+		}
+		delete(derivedPending, next)
+		if next.onChange != nil {
+			next.onChange()
+		}
+	}
+}
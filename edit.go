@@ -0,0 +1,97 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+)
+
+// editorCommand returns the editor from VISUAL or EDITOR, or the platform default editor, split into arguments
+func editorCommand() ([]string, error) {
+	editor := os.Getenv("VISUAL")
+	if editor == "" {
+		editor = os.Getenv("EDITOR")
+	}
+	if editor == "" {
+		editor = defaultEditor
+	}
+	args, err := splitArgs(editor, runtime.GOOS != "windows")
+	if err != nil {
+		return nil, fmt.Errorf(messages.InvalidEditor, editor, err.Error())
+	} else if len(args) == 0 {
+		return nil, errors.New(messages.NoEditor)
+	}
+	return args, nil
+}
+
+// editOptions opens a copy of the options file in the users editor and saves it back if the edited options are
+// valid. A missing options file starts with the options that would be saved. Invalid edits are not saved, the
+// copy is kept so that the changes are not lost.
+func editOptions(name string) error {
+	data, err := optionsStore.Load(name)
+	if os.IsNotExist(err) {
+		if data, err = jsonOptions(false); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	editor, err := editorCommand()
+	if err != nil {
+		return err
+	}
+	file, err := ioutil.TempFile("", "options-*.json")
+	if err != nil {
+		return err
+	}
+	tempName := file.Name()
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tempName)
+		return err
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], tempName)...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(tempName)
+		return fmt.Errorf(messages.EditorFailed, editor[0], err.Error())
+	}
+	edited, err := ioutil.ReadFile(tempName)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(edited, data) {
+		os.Remove(tempName)
+		fmt.Fprintf(output, messages.NoChanges+"\n", name)
+		return nil
+	}
+
+	saved := snapshotValues()
+	problems := validateOptionsData(tempName, edited)
+	restoreValues(saved)
+	if len(problems) > 0 {
+		for _, p := range problems {
+			fmt.Fprintln(output, p)
+		}
+		return fmt.Errorf(messages.EditProblemsFound, len(problems), tempName)
+	}
+	os.Remove(tempName)
+	if err := optionsStore.Save(name, edited, optionsFilePerm()); err != nil {
+		return err
+	}
+	fmt.Fprintf(output, messages.OptionsSaved+"\n", name)
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+)
+
+// RequireElevation makes the command fail with an *ElevationError before its function is called, unless the program
+// runs as root, or as an elevated Administrator on Windows.
+//
+//	cmdparse.Command("install", "", install).RequireElevation()
+func (c *CmdCommand) RequireElevation() *CmdCommand {
+	c.elevation = true
+	return c
+}
+
+// ElevationError is returned by the parse functions when a command added with RequireElevation is run without
+// elevated privileges
+type ElevationError struct {
+	Command string // Name of the command
+}
+
+func (e *ElevationError) Error() string {
+	return fmt.Sprintf(messages.ElevationRequired, e.Command, elevatedUser)
+}
+
+// Reexec runs the program again with the same arguments, elevated with sudo or with runas on Windows, and returns
+// the error of running it. This makes it possible to offer the user to run the command again with privileges.
+//
+//	if err := cmdparse.Parse(); err != nil {
+//	  if e, ok := err.(*cmdparse.ElevationError); ok && askYesNo(err.Error()+", run with sudo?") {
+//	    err = e.Reexec()
+//	  }
+//	}
+func (e *ElevationError) Reexec() error {
+	args := elevationCommand(os.Args)
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	return cmd.Run()
+}
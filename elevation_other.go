@@ -0,0 +1,22 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package cmdparser
+
+import "os"
+
+const elevatedUser = "root" // Privileges required by RequireElevation, used in errors
+
+// isElevated returns true if the process runs as root
+func isElevated() bool {
+	return os.Geteuid() == 0
+}
+
+// elevationCommand returns the commandline that runs args as root
+func elevationCommand(args []string) []string {
+	return append([]string{"sudo"}, args...)
+}
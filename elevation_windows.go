@@ -0,0 +1,35 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build windows
+// +build windows
+
+package cmdparser
+
+import (
+	"strings"
+	"syscall"
+)
+
+const elevatedUser = "Administrator" // Privileges required by RequireElevation, used in errors
+
+var procIsUserAnAdmin = syscall.NewLazyDLL("shell32.dll").NewProc("IsUserAnAdmin")
+
+// isElevated returns true if the process runs as an elevated Administrator
+func isElevated() bool {
+	if procIsUserAnAdmin.Find() != nil {
+		return false
+	}
+	r, _, _ := procIsUserAnAdmin.Call()
+	return r != 0
+}
+
+// elevationCommand returns the commandline that runs args as Administrator
+func elevationCommand(args []string) []string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = syscall.EscapeArg(a)
+	}
+	return []string{"runas", "/user:Administrator", strings.Join(quoted, " ")}
+}
@@ -0,0 +1,92 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// ByteEncoding selects how the value of a byte option is written on the commandline and in the options file
+type ByteEncoding int
+
+// Encodings of byte option values
+const (
+	Base64 ByteEncoding = iota // Base64 standard encoding, the default
+	Hex                        // Hexadecimal, like 00ff10
+	Raw                        // The bytes of the text as is
+)
+
+// format is the Format shown in help for byte options without a Format of their own
+func (e ByteEncoding) format() string {
+	switch e {
+	case Hex:
+		return "<hex>"
+	case Raw:
+		return "<text>"
+	default:
+		return "<base64>"
+	}
+}
+
+type encodedByteOption struct {
+	variable *[]byte
+	encoding ByteEncoding
+}
+
+func (b encodedByteOption) String() string { return b.encode(*b.variable) }
+func (b encodedByteOption) encode(data []byte) string {
+	switch b.encoding {
+	case Hex:
+		return hex.EncodeToString(data)
+	case Raw:
+		return string(data)
+	default:
+		return base64.StdEncoding.EncodeToString(data)
+	}
+}
+func (b encodedByteOption) Reset()                 { *b.variable = nil }
+func (b encodedByteOption) Get() interface{}       { return *b.variable }
+func (b encodedByteOption) jsonValue() interface{} { return b.String() }
+func (b encodedByteOption) Set(s string) error {
+	var v []byte
+	var err error
+	switch b.encoding {
+	case Hex:
+		v, err = hex.DecodeString(s)
+	case Raw:
+		v = []byte(s)
+	default:
+		v, err = base64.StdEncoding.DecodeString(s)
+	}
+	if err != nil {
+		return err
+	}
+	*b.variable = v
+	return nil
+}
+
+// Encoding sets the encoding of a byte option value on the commandline and in the options file. Hex is common for
+// keys and hashes in crypto tooling, Raw takes the text as is. Options without a Format show the encoding in help.
+//
+//	var key []byte
+//	cmdparse.ByteOption("key", "", "", "Encryption key", &key, cmdparse.Preference).Encoding(cmdparse.Hex)
+func (c *CmdOption) Encoding(encoding ByteEncoding) *CmdOption {
+	var variable *[]byte
+	switch v := c.Value.(type) {
+	case *byteOption:
+		variable = (*[]byte)(v)
+	case encodedByteOption:
+		variable = v.variable
+	default:
+		panic("Encoding is only supported by byte options")
+	}
+	c.Value = encodedByteOption{variable, encoding}
+	c.Default = c.Value.String()
+	if c.Format == "" {
+		c.Format = encoding.format()
+	}
+	return c
+}
@@ -0,0 +1,47 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ExportEnv returns the current value of every option as a KEY=VALUE pair for the environment of a subprocess.
+// Keys are the prefix followed by the option name in upper case, with characters other than letters and digits
+// replaced by _. Command options that share their name with another option are qualified by the command name.
+// Values have the same text format as on the commandline, lists as JSON arrays and byte options as base64.
+// The values of Masked and Sensitive options are exported as ********, pass them to a trusted subprocess explicitly.
+//
+//	cmd := exec.Command("worker")
+//	cmd.Env = append(os.Environ(), cmdparse.ExportEnv("MYTOOL_")...)
+func ExportEnv(prefix string) []string {
+	return exportEnv(prefix, true)
+}
+
+// exportEnv returns the environment of ExportEnv, with Masked and Sensitive options as ******** or left out
+func exportEnv(prefix string, masked bool) []string {
+	var env []string
+	for _, o := range optionList {
+		value := o.Value.String()
+		if o.masked() && !masked {
+			continue
+		} else if o.masked() {
+			value = maskedValue
+		}
+		env = append(env, envName(prefix, o.key())+"="+value)
+	}
+	return env
+}
+
+// envName returns the environment variable name of an option key
+func envName(prefix string, key string) string {
+	return prefix + strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, key)
+}
@@ -0,0 +1,82 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import "sync"
+
+// OptionEventType describes what happened to an option in an OptionEvent
+type OptionEventType int
+
+// Option lifecycle events
+const (
+	OptionRegistered OptionEventType = iota // Option was added to the parser
+	OptionChanged                           // Option value was set from the commandline or restored
+	OptionReset                             // Option value was reset by specifying an empty value
+	OptionLoaded                            // Option value was loaded from the options file
+	OptionSaved                             // Option value was saved to the options file
+)
+
+func (t OptionEventType) String() string {
+	switch t {
+	case OptionRegistered:
+		return "registered"
+	case OptionChanged:
+		return "changed"
+	case OptionReset:
+		return "reset"
+	case OptionLoaded:
+		return "loaded"
+	case OptionSaved:
+		return "saved"
+	}
+	return "unknown"
+}
+
+// OptionEvent is delivered to subscribers when something happens to an option
+type OptionEvent struct {
+	Type   OptionEventType // What happened
+	Option *CmdOption      // The option it happened to
+}
+
+var eventMutex sync.Mutex
+var subscribers = make(map[int]func(ev OptionEvent))
+var nextSubscriber int
+
+// Subscribe adds a function that is called for every option lifecycle event, so that GUI and TUI wrappers
+// can keep their views in sync with the live configuration. Events are delivered synchronously from the
+// goroutine causing them. The returned function removes the subscription.
+//
+//	unsubscribe := cmdparse.Subscribe(func(ev cmdparse.OptionEvent) {
+//	  fmt.Printf("%s %s = %s\n", ev.Type, ev.Option.Name, ev.Option.Value)
+//	})
+//	defer unsubscribe()
+func Subscribe(f func(ev OptionEvent)) (unsubscribe func()) {
+	eventMutex.Lock()
+	defer eventMutex.Unlock()
+
+	id := nextSubscriber
+	nextSubscriber++
+	subscribers[id] = f
+	return func() {
+		eventMutex.Lock()
+		defer eventMutex.Unlock()
+		delete(subscribers, id)
+	}
+}
+
+func emitEvent(t OptionEventType, o *CmdOption) {
+	eventMutex.Lock()
+	var list []func(ev OptionEvent)
+	for i := 0; i < nextSubscriber; i++ {
+		if f, ok := subscribers[i]; ok {
+			list = append(list, f)
+		}
+	}
+	eventMutex.Unlock()
+
+	for _, f := range list {
+		f(OptionEvent{Type: t, Option: o})
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SaveOptionsExcept saves the Preference options to the options file, except the named options that keep the value
+// they have in the options file, as if they were NoPersist. This makes it possible to save most preferences while an
+// option like a password given on the same commandline stays out of the file. This is the same as specifying
+// -saveoptions -except=<name>,<name> on the commandline.
+//
+//	err := cmdparse.SaveOptionsExcept("password", "token")
+func SaveOptionsExcept(names ...string) error {
+	if OptionsFile == "" {
+		return errors.New(messages.NoOptionsFile)
+	}
+	restore, err := excludeOptions(names)
+	if err != nil {
+		return err
+	}
+	defer restore()
+	_, err = saveOptions(OptionsFile)
+	return err
+}
+
+// excludeOptions makes the named options NoPersist until the returned function is called
+func excludeOptions(names []string) (func(), error) {
+	var excluded []*CmdOption
+	for _, name := range names {
+		o := findOption(name)
+		if o == nil {
+			return nil, fmt.Errorf(messages.InvalidOption, displayPrefix+name)
+		} else if !o.noPersist {
+			excluded = append(excluded, o)
+		}
+	}
+	for _, o := range excluded {
+		o.noPersist = true
+	}
+	return func() {
+		for _, o := range excluded {
+			o.noPersist = false
+		}
+	}, nil
+}
@@ -0,0 +1,68 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"fmt"
+	"os"
+)
+
+var errorHandler = defaultErrorHandler
+
+// defaultErrorHandler prints the error to stderr with a hint about help and returns 2, or 0 for ErrHelp, 130 for
+// ErrInterrupted and the exit code of the plugin for a PluginExitError without printing it
+func defaultErrorHandler(err error) int {
+	switch err {
+	case ErrHelp:
+		return 0
+	case ErrInterrupted:
+		return 130
+	}
+	if e, ok := err.(*PluginExitError); ok {
+		return e.Code
+	}
+	// Color follows stdout, as stderr is normally the same terminal
+	colorOutput = useColor()
+	fmt.Fprintln(os.Stderr, colorize(colorRed, fmt.Sprintf(messages.Error, err.Error())))
+	if len(helpFlags) > 0 && !compactErrors {
+		fmt.Fprintf(os.Stderr, messages.HelpHint+"\n", helpFlags[0])
+	}
+	return 2
+}
+
+// SetErrorHandler sets the function that presents the errors of Execute and returns the exit code of the program.
+// The handler is also called with ErrHelp after help or version information has been displayed. The default
+// handler prints the error to stderr followed by a hint about help, and returns 2, or 0 for ErrHelp and the exit code
+// of the plugin for a PluginExitError. Setting nil
+// restores the default handler.
+//
+//	cmdparse.SetErrorHandler(func(err error) int {
+//	  if err == cmdparse.ErrHelp {
+//	    return 0
+//	  }
+//	  log.Printf("mytool: %v", err)
+//	  return 1
+//	})
+func SetErrorHandler(f func(err error) int) {
+	if f == nil {
+		f = defaultErrorHandler
+	}
+	errorHandler = f
+}
+
+// Execute parses the commandline and runs the command like Parse, and returns the exit code for the program. Errors
+// are passed to the handler set with SetErrorHandler, which presents them and decides the exit code. Execute returns
+// 0 after the command function has returned.
+//
+//	func main() {
+//	  setupCommandline()
+//	  os.Exit(cmdparse.Execute())
+//	}
+func Execute() int {
+	if err := Parse(); err != nil {
+		return errorHandler(err)
+	}
+	return 0
+}
@@ -0,0 +1,71 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import "strconv"
+
+// Feature describes a feature flag registered with FeatureFlags
+type Feature struct {
+	Name         string // Name of the feature
+	Help         string // Help text describing the feature
+	Default      bool   // Feature is enabled unless it is disabled
+	Experimental bool   // Feature is experimental and named X:<name> on the commandline and in FeatureEnabled
+}
+
+const experimentalPrefix = "X:" // Namespace of experimental features
+
+var features = make(map[string]*bool) // State of every feature flag by name
+
+// FeatureFlags registers a hidden -enable-<name> and -disable-<name> option for every feature. Experimental
+// features are named X:<name>, like -enable-X:<name>, so it is clear that they may change or go away. The state
+// of a feature is saved with -saveoptions when it differs from its default, like any other Preference option.
+//
+//	cmdparse.FeatureFlags(
+//	  cmdparse.Feature{Name: "compression", Help: "Compress transfers", Default: true},
+//	  cmdparse.Feature{Name: "parallel-upload", Help: "Upload blocks in parallel", Experimental: true},
+//	)
+//
+//	mytool -disable-compression -enable-X:parallel-upload push
+//
+//	if cmdparse.FeatureEnabled("X:parallel-upload") {
+//	  ...
+//	}
+func FeatureFlags(list ...Feature) {
+	for _, f := range list {
+		name := f.Name
+		if f.Experimental {
+			name = experimentalPrefix + name
+		}
+		state := f.Default
+		features[name] = &state
+		BoolOption("enable-"+name, "", f.Help, &state, Preference|Hidden)
+		addOption("disable-"+name, "", "", f.Help, disabledOption{&state}, Hidden)
+	}
+}
+
+// FeatureEnabled returns true if the named feature is enabled. Experimental features are named X:<name>.
+// Features that have not been registered with FeatureFlags are never enabled.
+func FeatureEnabled(name string) bool {
+	if state, ok := features[name]; ok {
+		return *state
+	}
+	return false
+}
+
+// disabledOption is the negation of a bool, used by -disable-<name> options
+type disabledOption struct {
+	variable *bool
+}
+
+func (d disabledOption) String() string   { return strconv.FormatBool(!*d.variable) }
+func (d disabledOption) Reset()           { *d.variable = true }
+func (d disabledOption) Get() interface{} { return !*d.variable }
+func (d disabledOption) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err == nil {
+		*d.variable = !v
+	}
+	return err
+}
@@ -0,0 +1,85 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import "flag"
+
+// flagValue is an option value of a flag imported from a flag.FlagSet
+type flagValue struct {
+	value    flag.Value
+	defValue string
+}
+
+func (f flagValue) String() string { return f.value.String() }
+func (f flagValue) Reset()         { f.value.Set(f.defValue) }
+func (f flagValue) Set(s string) error {
+	return f.value.Set(s)
+}
+func (f flagValue) Get() interface{} {
+	if g, ok := f.value.(flag.Getter); ok {
+		return g.Get()
+	}
+	return f.value.String()
+}
+
+// flagBoolValue is a flagValue of a bool flag, that does not need a value on the commandline
+type flagBoolValue struct{ flagValue }
+
+// ImportFlagSet adds every flag defined in a flag.FlagSet as an option of the command group, blank for global
+// options, so that flags registered by libraries using the standard flag package are parsed, listed in help and
+// can be saved as preferences together with the other options. The usage of a flag is used as help text and a
+// name in back quotes becomes the format, the same way as in flag.PrintDefaults. The added options are returned.
+//
+//	fs := flag.NewFlagSet("glog", flag.ContinueOnError)
+//	glog.RegisterFlags(fs)
+//	cmdparse.ImportFlagSet(fs, "")
+func ImportFlagSet(fs *flag.FlagSet, group string) []*CmdOption {
+	var added []*CmdOption
+	fs.VisitAll(func(f *flag.Flag) {
+		name, usage := flag.UnquoteUsage(f)
+		format := ""
+		if name != "" {
+			format = "<" + name + ">"
+		}
+		var value Value = flagValue{f.Value, f.DefValue}
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			value, format = flagBoolValue{flagValue{f.Value, f.DefValue}}, ""
+		}
+		added = append(added, addOption(f.Name, group, format, usage, value, Standard))
+	})
+	return added
+}
+
+// optionFlag is a flag.Value that sets an option
+type optionFlag struct{ option *CmdOption }
+
+func (f optionFlag) String() string {
+	if f.option == nil {
+		return "" // flag.PrintDefaults calls String on a zero value
+	}
+	return f.option.Value.String()
+}
+func (f optionFlag) Set(s string) error {
+	if err := f.option.apply(s, SourceCommandLine); err != nil {
+		return f.option.invalidValue(f.option.Name, s, err)
+	}
+	return nil
+}
+func (f optionFlag) Get() interface{} { return f.option.Value.Get() }
+func (f optionFlag) IsBoolFlag() bool { return !takesValue(f.option) }
+
+// ExportFlagSet returns a flag.FlagSet with a flag for every option, named by its options file key, so that code
+// written for the standard flag package can parse into the options or list them. Setting a flag sets the option
+// the same way as on the commandline.
+//
+//	fs := cmdparse.ExportFlagSet()
+//	fs.Parse([]string{"-verbose", "-server=localhost"})
+func ExportFlagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(commandName, flag.ContinueOnError)
+	for _, o := range optionList {
+		fs.Var(optionFlag{o}, o.key(), o.Help)
+	}
+	return fs
+}
@@ -0,0 +1,121 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package cmdparser
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// typeHandler converts option values of type T to and from their commandline and options file text format
+type typeHandler[T any] struct {
+	parse   func(string) (T, error)
+	format  func(T) string
+	numeric bool // Values are numbers, they are saved as JSON numbers and can follow the option as -<number>
+}
+
+var typeHandlers = make(map[reflect.Type]interface{}) // *typeHandler[T] by reflect type of T
+
+func init() {
+	registerType(func(s string) (int, error) { v, err := strconv.ParseInt(s, 0, 0); return int(v), err }, nil, true)
+	registerType(func(s string) (int8, error) { v, err := strconv.ParseInt(s, 0, 8); return int8(v), err }, nil, true)
+	registerType(func(s string) (int16, error) { v, err := strconv.ParseInt(s, 0, 16); return int16(v), err }, nil, true)
+	registerType(func(s string) (int32, error) { v, err := strconv.ParseInt(s, 0, 32); return int32(v), err }, nil, true)
+	registerType(func(s string) (uint, error) { v, err := strconv.ParseUint(s, 0, 0); return uint(v), err }, nil, true)
+	registerType(func(s string) (uint8, error) { v, err := strconv.ParseUint(s, 0, 8); return uint8(v), err }, nil, true)
+	registerType(func(s string) (uint16, error) { v, err := strconv.ParseUint(s, 0, 16); return uint16(v), err }, nil, true)
+	registerType(func(s string) (uint32, error) { v, err := strconv.ParseUint(s, 0, 32); return uint32(v), err }, nil, true)
+	registerType(func(s string) (uint64, error) { return strconv.ParseUint(s, 0, 64) }, nil, true)
+	registerType(func(s string) (float32, error) { v, err := strconv.ParseFloat(s, 32); return float32(v), err }, nil, true)
+	registerType(time.ParseDuration, time.Duration.String, false)
+}
+
+func registerType[T any](parse func(string) (T, error), format func(T) string, numeric bool) {
+	if format == nil {
+		format = func(v T) string { return fmt.Sprint(v) }
+	}
+	typeHandlers[reflect.TypeOf((*T)(nil)).Elem()] = &typeHandler[T]{parse: parse, format: format, numeric: numeric}
+}
+
+// RegisterType makes it possible to use Option with values of type T. The parse function converts commandline and
+// options file text to a value and format converts it back, if format is nil the value is formatted with fmt.Sprint.
+// Registering a type that already has a parser replaces it.
+//
+//	cmdparse.RegisterType(net.ParseMAC, net.HardwareAddr.String)
+func RegisterType[T any](parse func(string) (T, error), format func(T) string) {
+	registerType(parse, format, false)
+}
+
+type genericOption[T any] struct {
+	variable *T
+	handler  *typeHandler[T]
+}
+
+func (g *genericOption[T]) String() string   { return g.handler.format(*g.variable) }
+func (g *genericOption[T]) Reset()           { var zero T; *g.variable = zero }
+func (g *genericOption[T]) Get() interface{} { return *g.variable }
+func (g *genericOption[T]) Set(s string) error {
+	v, err := g.handler.parse(s)
+	if err == nil {
+		*g.variable = v
+	}
+	return err
+}
+func (g *genericOption[T]) jsonValue() interface{} {
+	s := g.String()
+	if g.handler.numeric {
+		// Numbers that would not survive being loaded as float64, like large uint64 values, are saved as strings
+		if f, err := strconv.ParseFloat(s, 64); err == nil && strconv.FormatFloat(f, 'f', -1, 64) == s {
+			return *g.variable
+		}
+	}
+	return s
+}
+func (g *genericOption[T]) numeric() bool { return g.handler.numeric }
+
+// Option adds an option of any type T with the specified name, command group, help text, variable pointer and flags.
+// The types of BoolOption, BoolPtrOption, IntOption, FloatOption, StringOption, StringPtrOption, StringListOption,
+// IntListOption, FloatListOption, MapOption and ByteOption behave exactly like those options, other signed and
+// unsigned integer types, float32 and time.Duration are built in.
+// Other types must first be registered with RegisterType, Option panics if there is no parser for T.
+//
+//	var timeout time.Duration = 30 * time.Second
+//	cmdparse.Option("timeout", "", "<duration>", "Connection timeout", &timeout, cmdparse.Preference)
+func Option[T any](name string, cmd string, format string, help string, variable *T, flags int) *CmdOption {
+	switch v := interface{}(variable).(type) {
+	case *bool:
+		return addOption(name, cmd, format, help, (*boolOption)(v), flags)
+	case **bool:
+		return addOption(name, cmd, format, help, boolPtrOption{v}, flags)
+	case *int64:
+		return addOption(name, cmd, format, help, (*intOption)(v), flags)
+	case *float64:
+		return addOption(name, cmd, format, help, (*floatOption)(v), flags)
+	case *string:
+		return addOption(name, cmd, format, help, (*stringOption)(v), flags)
+	case **string:
+		return addOption(name, cmd, format, help, stringPtrOption{v}, flags)
+	case *[]string:
+		return addOption(name, cmd, format, help, (*stringListOption)(v), flags)
+	case *[]int64:
+		return addOption(name, cmd, format, help, (*intListOption)(v), flags)
+	case *[]float64:
+		return addOption(name, cmd, format, help, (*floatListOption)(v), flags)
+	case *map[string]string:
+		return addOption(name, cmd, format, help, (*mapOption)(v), flags)
+	case *[]byte:
+		return addOption(name, cmd, format, help, (*byteOption)(v), flags)
+	}
+	handler, ok := typeHandlers[reflect.TypeOf(variable).Elem()].(*typeHandler[T])
+	if !ok {
+		panic(fmt.Sprintf("Option -%s has type %T that is not registered with RegisterType", name, *variable))
+	}
+	return addOption(name, cmd, format, help, &genericOption[T]{variable: variable, handler: handler}, flags)
+}
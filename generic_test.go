@@ -0,0 +1,63 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build go1.18
+// +build go1.18
+
+package cmdparser
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestOptionBuiltinTypes(t *testing.T) {
+	Reset()
+	var ints []int64
+	var floats []float64
+	var labels map[string]string
+	if _, ok := Option("int", "", "<n>", "", &ints, 0).Value.(*intListOption); !ok {
+		t.Error("*[]int64 is not an int list option")
+	}
+	if _, ok := Option("float", "", "<n>", "", &floats, 0).Value.(*floatListOption); !ok {
+		t.Error("*[]float64 is not a float list option")
+	}
+	if _, ok := Option("label", "", "<key>=<value>", "", &labels, 0).Value.(*mapOption); !ok {
+		t.Error("*map[string]string is not a map option")
+	}
+}
+
+func TestUnsignedOptionsFileValues(t *testing.T) {
+	tests := []struct {
+		arg   string
+		saved string
+	}{
+		{"7", "7"},
+		{"4000000000", "4000000000"},
+		{"18446744073709551615", "\"18446744073709551615\""},
+	}
+	for _, test := range tests {
+		Reset()
+		var value uint64
+		o := Option("value", "", "<n>", "", &value, 0)
+		if err := o.Value.Set(test.arg); err != nil {
+			t.Fatalf("%s: %s", test.arg, err)
+		}
+		data, err := json.Marshal(o.jsonValue(false))
+		if err != nil {
+			t.Fatalf("%s: %s", test.arg, err)
+		} else if string(data) != test.saved {
+			t.Errorf("%s: saved as %s, expected %s", test.arg, data, test.saved)
+		}
+
+		var loaded interface{}
+		json.Unmarshal(data, &loaded)
+		value = 0
+		if err := setJSONValue(o, loaded); err != nil {
+			t.Errorf("%s: %s", test.arg, err)
+		} else if o.Value.String() != test.arg {
+			t.Errorf("%s: loaded as %s", test.arg, o.Value.String())
+		}
+	}
+}
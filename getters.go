@@ -0,0 +1,90 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// GetBool returns the value of a bool option, false if a BoolPtrOption is not set. An error is returned if the option
+// holds a different type.
+//
+//	if verbose, err := cmdparse.Lookup("verbose").GetBool(); err == nil && verbose {
+//	  log.SetOutput(os.Stderr)
+//	}
+func (c *CmdOption) GetBool() (bool, error) {
+	switch v := c.Value.Get().(type) {
+	case bool:
+		c.recordAccess()
+		return v, nil
+	case *bool:
+		c.recordAccess()
+		return v != nil && *v, nil
+	}
+	return false, c.typeError("bool")
+}
+
+// GetInt64 returns the value of an integer option of any size, including durations. An error is returned if the
+// option holds a different type or an unsigned value that does not fit in an int64.
+func (c *CmdOption) GetInt64() (int64, error) {
+	v := reflect.ValueOf(c.Value.Get())
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		c.recordAccess()
+		return v.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if v.Uint() <= math.MaxInt64 {
+			c.recordAccess()
+			return int64(v.Uint()), nil
+		}
+	}
+	return 0, c.typeError("int64")
+}
+
+// GetString returns the value of a string option, a blank string if a StringPtrOption is not set. An error is returned
+// if the option holds a different type, or is a SecretOption which can only be read through its Secret.
+func (c *CmdOption) GetString() (string, error) {
+	if _, secret := c.Value.(secretOption); !secret {
+		switch v := c.Value.Get().(type) {
+		case string:
+			c.recordAccess()
+			return v, nil
+		case *string:
+			c.recordAccess()
+			if v == nil {
+				return "", nil
+			}
+			return *v, nil
+		}
+	}
+	return "", c.typeError("string")
+}
+
+// GetStringList returns a copy of the values of a string list option. An error is returned if the option holds a
+// different type.
+func (c *CmdOption) GetStringList() ([]string, error) {
+	if v, ok := c.Value.Get().([]string); ok {
+		c.recordAccess()
+		return append([]string{}, v...), nil
+	}
+	return nil, c.typeError("[]string")
+}
+
+// recordAccess records the read of a Sensitive option through a getter, see SecretAccesses
+func (c *CmdOption) recordAccess() {
+	if c.Flags&Sensitive > 0 {
+		recordAccess(c.Name)
+	}
+}
+
+// typeError returns the error of a typed getter for an option that does not hold the type
+func (c *CmdOption) typeError(expected string) error {
+	if _, secret := c.Value.(secretOption); secret {
+		return fmt.Errorf(messages.SecretValue, displayPrefix+c.Name)
+	}
+	return fmt.Errorf(messages.WrongValueType, displayPrefix+c.Name, c.Value.Get(), expected)
+}
@@ -0,0 +1,122 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import "fmt"
+
+// CommandInfo is a copy of the definition of a command, for frontends that present the commands in other ways
+type CommandInfo struct {
+	Name            string            `json:"name"`                      // Full name of the command, parent and child separated by a space
+	Help            string            `json:"help,omitempty"`            // Help text, normally describing the arguments
+	ShortHelp       string            `json:"shortHelp,omitempty"`       // Shorter help text for summaries
+	Parent          string            `json:"parent,omitempty"`          // Name of the parent command of a child command
+	Runnable        bool              `json:"runnable"`                  // Command has a function, otherwise it only groups child commands
+	Category        string            `json:"category,omitempty"`        // Category that the command is listed under in help
+	RequiredOptions []string          `json:"requiredOptions,omitempty"` // Options required by the command with RequireOptions
+	Examples        []string          `json:"examples,omitempty"`        // Example commandlines set with Examples
+	Annotations     map[string]string `json:"annotations,omitempty"`     // Metadata set with Annotate
+}
+
+// OptionInfo is a copy of the definition and current value of an option, for frontends that present the options in
+// other ways, like a form with a field for each option
+type OptionInfo struct {
+	Name         string            `json:"name"`                   // Name of the option
+	Key          string            `json:"key"`                    // Key of the option in the options file
+	Command      string            `json:"command,omitempty"`      // Command group, blank for global options
+	Type         string            `json:"type"`                   // Go type of the value, like bool, int64 or []string
+	Format       string            `json:"format,omitempty"`       // Format text like <ip>:<port>
+	Help         string            `json:"help,omitempty"`         // Help text
+	LongHelp     string            `json:"longHelp,omitempty"`     // Detailed help text set with LongHelp
+	Default      string            `json:"default,omitempty"`      // Default value in text format
+	DefaultText  string            `json:"defaultText,omitempty"`  // Text shown in help instead of the default value
+	Value        string            `json:"value,omitempty"`        // Current value in text format, Masked options are masked
+	Source       string            `json:"source"`                 // Where the current value was set from
+	Flags        int               `json:"flags"`                  // Option flags like Required, Preference and Hidden
+	Example      string            `json:"example,omitempty"`      // Example value set with Example
+	Aliases      map[string]string `json:"aliases,omitempty"`      // Value aliases set with ValueAlias
+	Min          *float64          `json:"min,omitempty"`          // Minimum value set with Min
+	Max          *float64          `json:"max,omitempty"`          // Maximum value set with Max
+	Pattern      string            `json:"pattern,omitempty"`      // Regular expression set with MatchRegex
+	Deprecated   *string           `json:"deprecated,omitempty"`   // Deprecation message set with Deprecated
+	Inherit      bool              `json:"inherit,omitempty"`      // Option is inherited by child commands
+	InheritValue bool              `json:"inheritValue,omitempty"` // Default is the value of the outer option of the same name
+	Recent       []string          `json:"recent,omitempty"`       // Recent values of MRU options
+	Annotations  map[string]string `json:"annotations,omitempty"`  // Metadata set with Annotate
+}
+
+// Commands returns a copy of the definition of every command in help order. Changing the returned values does not
+// change the commands.
+func Commands() []CommandInfo {
+	var list []CommandInfo
+	for _, c := range sortedCommands() {
+		info := CommandInfo{Name: c.Command, Help: c.Help, ShortHelp: c.ShortHelp, Runnable: c.runnable(),
+			Category: c.categoryName(), RequiredOptions: append([]string(nil), c.requiredOptions...),
+			Examples: append([]string(nil), c.examples...), Annotations: copyMap(c.Annotations)}
+		if c.parent != nil {
+			info.Parent = c.parent.Command
+		}
+		list = append(list, info)
+	}
+	return list
+}
+
+// Options returns a copy of the definition and current value of every option in help order, including hidden
+// options. Changing the returned values does not change the options.
+//
+//	for _, o := range cmdparse.Options() {
+//	  if o.Flags&cmdparse.Hidden == 0 {
+//	    form.AddField(o.Name, o.Type, o.Value, o.Help)
+//	  }
+//	}
+func Options() []OptionInfo {
+	var list []OptionInfo
+	for _, o := range sortedOptions() {
+		info := OptionInfo{Name: o.Name, Key: o.key(), Command: o.Group, Type: fmt.Sprintf("%T", o.Value.Get()),
+			Format: o.Format, Help: o.Help, Default: o.Default, Value: o.Value.String(), Source: o.source.String(),
+			LongHelp: o.longHelp, DefaultText: o.defaultText, Flags: o.Flags, Example: o.example, Inherit: o.inherit,
+			InheritValue: o.inheritValue,
+			Recent:       append([]string(nil), o.recent...), Annotations: copyMap(o.Annotations)}
+		if o.masked() {
+			info.Value = maskedValue
+			if info.Default != "" {
+				info.Default = maskedValue
+			}
+		}
+		if len(o.aliases) > 0 {
+			info.Aliases = make(map[string]string)
+			for _, a := range o.aliases {
+				info.Aliases[a[0]] = a[1]
+			}
+		}
+		if o.min != nil {
+			min := *o.min
+			info.Min = &min
+		}
+		if o.max != nil {
+			max := *o.max
+			info.Max = &max
+		}
+		if o.pattern != nil {
+			info.Pattern = o.pattern.String()
+		}
+		if o.deprecated != nil {
+			message := *o.deprecated
+			info.Deprecated = &message
+		}
+		list = append(list, info)
+	}
+	return list
+}
+
+func copyMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,25 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+// ParseKnown sets the registered options found in args and returns all other arguments untouched and in order, so
+// that a wrapper tool can take its own options and pass everything else on to the program it wraps. Options are
+// parsed exactly like by Parse, and the policy and options file are loaded first. Built-in flags, including help,
+// are passed on like other unknown options. Commands are not dispatched, and arguments following -- are returned
+// as is, including the --.
+//
+//	cmdparse.BoolOption("dry-run", "", "Show the command instead of running it", &dryRun, cmdparse.Standard)
+//	rest, err := cmdparse.ParseKnown(os.Args[1:])
+//	if err != nil {
+//	  log.Fatal(err)
+//	}
+//	cmd := exec.Command("docker", rest...)
+func ParseKnown(args []string) (unknown []string, err error) {
+	result, err := parseExclusive(append([]string{commandName}, args...), parseKnown)
+	if err != nil {
+		return nil, err
+	}
+	return result.known, nil
+}
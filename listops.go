@@ -0,0 +1,102 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// List and map options loaded from the options file can be changed on the commandline with merge operators
+//
+//	-ignore=*.tmp     adds *.tmp to the list (same as -ignore+=*.tmp)
+//	-ignore+=*.tmp    adds *.tmp to the list
+//	-ignore-=*.tmp    removes *.tmp from the list
+//	-ignore==*.tmp    replaces the list with *.tmp
+//
+// Operators only apply to list and map options. Values are removed from map options by key.
+const (
+	listAppend  = '+'
+	listRemove  = '-'
+	listReplace = '='
+)
+
+// listOperator splits a merge operator from the name and value of a list option, it returns 0 if there is none
+func listOperator(pair []string, scope *CmdCommand) (byte, []string) {
+	if len(pair) < 2 {
+		return 0, pair
+	}
+	if strings.HasPrefix(pair[1], "=") {
+		if o := findScopedOption(pair[0], scope); o != nil && o.accumulates() {
+			return listReplace, []string{pair[0], pair[1][1:]}
+		}
+	}
+	if n := len(pair[0]); n > 1 && (pair[0][n-1] == listAppend || pair[0][n-1] == listRemove) && findScopedOption(pair[0], scope) == nil {
+		if o := findScopedOption(pair[0][:n-1], scope); o != nil && o.accumulates() {
+			return pair[0][n-1], []string{pair[0][:n-1], pair[1]}
+		}
+	}
+	return 0, pair
+}
+
+// remove removes a value from a list option or a key from a map option
+func (c *CmdOption) remove(value string) error {
+	if c.Flags&Locked > 0 {
+		return c.lockedError()
+	}
+	switch v := c.Value.(type) {
+	case *stringListOption:
+		*v = removeStrings(*v, []string{value})
+	case *splitListOption:
+		list, err := splitList(value)
+		if err != nil {
+			return err
+		}
+		*v = removeStrings(*v, list)
+	case *intListOption:
+		i, err := strconv.ParseInt(value, 0, 64)
+		if err != nil {
+			return err
+		}
+		var kept intListOption
+		for _, e := range *v {
+			if e != i {
+				kept = append(kept, e)
+			}
+		}
+		*v = kept
+	case *floatListOption:
+		f, err := strconv.ParseFloat(c.localeValue(value), 64)
+		if err != nil {
+			return err
+		}
+		var kept floatListOption
+		for _, e := range *v {
+			if e != f {
+				kept = append(kept, e)
+			}
+		}
+		*v = kept
+	case *mapOption:
+		delete(*v, strings.SplitN(value, "=", 2)[0])
+	default:
+		return errors.New(messages.RemoveNotSupported)
+	}
+	c.source = SourceCommandLine
+	emitEvent(OptionChanged, c)
+	c.doChange()
+	return nil
+}
+
+func removeStrings(list []string, remove []string) []string {
+	var kept []string
+	for _, s := range list {
+		if !contains(remove, s) {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,86 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+var localeNumbers bool
+var thousandsSeparators bool
+
+// Groups of three digits separated by commas, like 1,000,000
+var thousandsPattern = regexp.MustCompile(`^[+-]?[0-9]{1,3}(,[0-9]{3})+$`)
+
+// Languages that write numbers with a decimal comma
+var decimalCommaLanguages = []string{"az", "be", "bg", "bs", "ca", "cs", "da", "de", "el", "es", "et", "eu", "fi", "fo",
+	"fr", "gl", "hr", "hu", "id", "is", "it", "ka", "kk", "ky", "lt", "lv", "mk", "mn", "nb", "nl", "nn", "no", "pl",
+	"pt", "ro", "ru", "sk", "sl", "sq", "sr", "sv", "tr", "uk", "uz", "vi"}
+
+// SetLocaleNumbers makes float options accept a decimal comma, like -ratio=3,14, when the locale of the user writes
+// numbers that way. The locale is read from the LC_ALL, LC_NUMERIC or LANG environment variable. Values are still
+// shown in help and saved to the options file with a decimal point, and a decimal point is always accepted.
+//
+//	cmdparse.SetLocaleNumbers(true)
+func SetLocaleNumbers(enable bool) {
+	localeNumbers = enable
+}
+
+// SetThousandsSeparators makes integer options accept commas between groups of three digits, like -size=1,000,000.
+// Underscores between digits, like -size=1_000_000, are always accepted. Integer list options do not accept commas.
+//
+//	cmdparse.SetThousandsSeparators(true)
+func SetThousandsSeparators(enable bool) {
+	thousandsSeparators = enable
+}
+
+// digitValue returns the value of an integer option with digit separators removed
+func (c *CmdOption) digitValue(value string) string {
+	_, single := c.Value.(*intOption)
+	_, list := c.Value.(*intListOption)
+	if !single && !list && c.base == 0 && c.bits == 0 {
+		return value
+	}
+	if thousandsSeparators && !list && thousandsPattern.MatchString(value) {
+		value = strings.Replace(value, ",", "", -1)
+	}
+	if strings.Contains(value, "_") && !strings.HasPrefix(value, "_") && !strings.HasSuffix(value, "_") {
+		value = strings.Replace(value, "_", "", -1)
+	}
+	return value
+}
+
+// decimalComma returns true if the locale of the user writes numbers with a decimal comma
+func decimalComma() bool {
+	locale := os.Getenv("LC_ALL")
+	if locale == "" {
+		locale = os.Getenv("LC_NUMERIC")
+	}
+	if locale == "" {
+		locale = os.Getenv("LANG")
+	}
+	if i := strings.IndexAny(locale, "_-.@"); i >= 0 {
+		locale = locale[:i]
+	}
+	return contains(decimalCommaLanguages, strings.ToLower(locale))
+}
+
+// localeValue returns the value of a float option with a decimal comma changed to a decimal point
+func (c *CmdOption) localeValue(value string) string {
+	if !localeNumbers || strings.Count(value, ",") != 1 || strings.Contains(value, ".") {
+		return value
+	}
+	switch c.Value.(type) {
+	case *floatOption, *floatListOption:
+	default:
+		return value
+	}
+	if !decimalComma() {
+		return value
+	}
+	return strings.Replace(value, ",", ".", 1)
+}
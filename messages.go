@@ -0,0 +1,349 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import "reflect"
+
+// Messages is a catalog of the text generated for help, warnings and errors. Texts with verbs are fmt formats that
+// receive the values described next to each field, in that order.
+type Messages struct {
+	Usage            string // Heading of the command list
+	Options          string // Heading of the global options
+	CommandOptions   string // Heading of the options of a command, command name
+	InheritedOptions string // Heading of the options inherited from a parent command, parent command name
+	HelpFullHint     string // Hint shown in summary help, help flag
+	CommandCategory  string // Heading of the commands in a category, category name
+	Examples         string // Heading of the examples of a command
+	NoHelpMatches    string // Printed when no command or option matches a help search, keyword
+
+	Required     string // Marks a required option
+	Preference   string // Marks an option that is saved in the options file
+	Locked       string // Marks an option locked by policy
+	Deprecated   string // Marks a deprecated option
+	Inherited    string // Marks an option inherited by subcommands
+	DefaultOn    string // Marks a bool option that is on by default
+	Default      string // Default value of an option, value
+	ExampleText  string // Example value of an option, example
+	Aliases      string // Value aliases of an option, comma separated list of alias=value
+	CurrentlySet string // Source of the current value of an option that cannot be shown, source
+	Currently    string // Current value of an option, value and source
+	Hidden       string // Marks a hidden option in the list of missing required options
+	NotSaved     string // Saved value of an option that is not in the options file, shown by -showoptions=diff
+	Range        string // Range of values set with Min and Max, minimum and maximum
+	Min          string // Minimum value set with Min, minimum
+	Max          string // Maximum value set with Max, maximum
+	Matching     string // Pattern set with MatchRegex, regular expression
+
+	SourceDefault     string // Source of an option with its default value
+	SourceOptionsFile string // Source of an option loaded from the options file
+	SourceCommandLine string // Source of an option specified on the commandline
+	SourceProgram     string // Source of an option set by the application
+	SourcePolicy      string // Source of an option set by the policy file
+
+	SaveOptionsHelp     string // Help of -saveoptions, options file
+	ExceptHelp          string // Help of -except, -saveoptions flag
+	ShowOptionsHelp     string // Help of -showoptions
+	ValidateOptionsHelp string // Help of -validateoptions
+	EditOptionsHelp     string // Help of -editoptions
+	SaveProfileHelp     string // Help of -save-profile
+	RunProfileHelp      string // Help of -run-profile
+	ShowConfigHelp      string // Help of -showconfig
+	ProfileHelp         string // Help of -profile
+	ProfilesHelp        string // Help of -profiles
+	VersionHelp         string // Help of -version
+	CheckUpdateHelp     string // Help of -check-update
+
+	Warning                string // Warning written to stderr by the default warning handler, warning message
+	DuplicateOption        string // Warning for an option specified more than once, option flag
+	DeprecatedOption       string // Warning for a deprecated option, option flag
+	DeprecatedOptionReason string // Warning for a deprecated option with a message, option flag and message
+	OptionsSaved           string // Confirmation of -saveoptions, options file
+	UpdateAvailable        string // Result of -check-update with a newer version, latest version and current version
+	UpToDate               string // Result of -check-update without a newer version, program name and version
+	ProfileSaved           string // Confirmation of -save-profile, profile name and run profiles file
+	NoProblems             string // Result of -validateoptions without problems, options file
+	NoDifferences          string // Result of -showoptions=diff without differences, options file
+	NoChanges              string // Result of -editoptions when the file was not changed, options file
+	NoVersion              string // Result of -version when no version has been set
+	DefaultCommand         string // Name of the default command in -explain
+	ExplainCommand         string // Command printed by -explain, command name
+	ExplainArguments       string // Arguments printed by -explain, quoted arguments
+	ExplainRawArguments    string // Arguments following -- printed by -explain, quoted arguments
+	CommandOption          string // Scope of a command option in -showconfig, command name
+	RecentNotSaved         string // Warning when recent values cannot be saved, reason
+	ReloadFailed           string // Warning when the options file cannot be reloaded, reason
+	NormalizedKey          string // Warning for an options file key read as another option, key and option
+	UnknownOptionIgnored   string // Warning for an ignored unknown option in an options file, unknown option error
+	SharedSensitiveFile    string // Warning for an options file with a sensitive option that other users can read, options file, option key and permissions
+
+	Error                  string // Error printed to stderr by the default error handler of Execute, error message
+	InvalidOption          string // Error for an unknown option, option flag
+	InvalidValue           string // Error for an invalid option value, option name, value and reason
+	DuplicateOptionError   string // Error for an option that cannot be specified more than once, option flag
+	ExpectedFormat         string // Appended to invalid value errors, format of the option
+	ExpectedExample        string // Appended to invalid value errors, example value
+	MissingRequiredOption  string // Error for a missing required option, option
+	MissingRequiredOptions string // Error for missing required options, comma separated list of options
+	MissingOneOf           string // Error when none of the options of a RequireOneOf group is set, comma separated list of options
+	OnlyOneOf              string // Error when more than one option of a RequireExactlyOneOf group is set, comma separated list of options
+	ExceptWithoutSave      string // Error when -except is used without -saveoptions, -except and -saveoptions flags
+	UnknownProfile         string // Error for a profile that does not exist in the options file, profile name
+	UpdateCheckFailed      string // Error when the update checker fails, reason
+	MissingCommand         string // Error when no command was specified
+	MissingSubCommand      string // Error when a parent command was specified without a child command, parent command name
+	InvalidCommand         string // Error for an unknown command, command name
+	CommandNotAvailable    string // Error for a command that is not available on this operating system, command name and GOOS
+	OptionNotAvailable     string // Error for an option that is not available on this operating system, option flag and GOOS
+	CompactUsage           string // Usage printed with SetCompactErrors, usage line
+	DidYouMean             string // Suggestion printed with SetCompactErrors, command name
+	HelpHint               string // Hint printed after errors by the default error handler and after the usage of SetCompactErrors, help flag
+	ValueFileError         string // Error when the file of an @file value cannot be read, option name and reason
+	UnknownRequiredOption  string // Error for a command that requires an option that does not exist, command name and option flag
+	ProblemsFound          string // Error of -validateoptions, number of problems and options file
+	EditProblemsFound      string // Error of -editoptions when the edited file is invalid, number of problems and kept file
+	InvalidEditor          string // Error for an editor command that cannot be split into arguments, editor and reason
+	NoEditor               string // Error of -editoptions when no editor is set
+	EditorFailed           string // Error when the editor cannot be run, editor and reason
+	InvalidShowOptionsMode string // Error for an unknown -showoptions mode, -showoptions flag and mode
+	NoOptionsFile          string // Error when saving or reloading options without an options file
+	OptionLockedError      string // Error when changing an option locked by policy, option flag
+	PluginFailed           string // Error when a plugin cannot be started, plugin path and reason
+	PluginExited           string // Error when a plugin exits with a non-zero code, plugin path and exit code
+	ElevationRequired      string // Error for a command that requires elevated privileges, command name and user
+	TimedOut               string // Error when a command is cancelled by -timeout, duration
+	SaveOptionFailed       string // Error when the value of an option cannot be transformed for saving, option name and reason
+	FileNotObject          string // Error for an options file that is not a JSON object
+	FileInvalidVersion     string // Error for an options file version that is not an integer, version
+	FileNewerVersion       string // Error for an options file version that is not supported, version and supported version
+	FileUnknownOption      string // Error for an unknown option in an options file, key
+	FileNotPreference      string // Error for an option in an options file that is not a preference option, key
+	FileInvalidValue       string // Error for an invalid value in an options file, key, reason and expected format
+	FileOptionLocked       string // Error for an option in an options file that is locked by policy, key
+	ObjectNotSupported     string // Error for a JSON object value of an option that is not a map option
+	ObjectValueNotString   string // Error for a JSON object value that is not a string, value and key
+	ArrayValueNotString    string // Error for a JSON array element that is not a string, element
+	MigrationFailed        string // Error when options cannot be migrated, options file version and reason
+	NotPreference          string // Error of the config command for a key that is not a preference, key
+	MissingConfigCommand   string // Error when the config command is used without a subcommand
+	ConfigSingleValue      string // Error of config set with more than one value, key
+	ConfigArguments        string // Error for a config command with the wrong number of arguments, config command
+	UnknownConfigCommand   string // Error for an unknown config command, config command
+	NestedResponseFiles    string // Error when response files are nested too deep
+	ResponseFileError      string // Error when a response file cannot be read, file name and reason
+	RunProfilesError       string // Error when the run profiles cannot be read, run profiles file and reason
+	NestedRunProfiles      string // Error when run profiles are nested too deep
+	UnknownRunProfile      string // Error for a run profile that does not exist, profile name
+	InvalidVersionFormat   string // Error for an unknown -version format, format
+	InvalidCommandDefault  string // Error for an invalid command default, value, option flag, command name and reason
+	InvalidInheritedValue  string // Error for an invalid inherited value, value, option flag, command name and reason
+	SecretValue            string // Error when the value of a secret option is read with a getter, option flag
+	WrongValueType         string // Error when a getter does not match the option type, option flag, value and type
+	InvalidRange           string // Error for a range where the start is greater than the end, start and end
+	UnsupportedShell       string // Error for an unsupported shell in alias, shell
+	UnexpectedEndOfLine    string // Error for a line that ends with an escape character
+	UnexpectedEndOfValue   string // Error for a value that ends with an escape character
+	UnterminatedQuote      string // Error for a quote that is not closed, quote character
+	BindNotStruct          string // Error when Bind is not given a pointer to a struct
+	BindUnexported         string // Error when Bind finds an unexported field, field name
+	BindInvalidFlags       string // Error for invalid flags of a Bind field, field name and reason
+	BindUnknownFlag        string // Reason for an unknown flag of a Bind field, flag
+	BindUnsupportedType    string // Error for a Bind field of an unsupported type, field name and type
+	BindInvalidDefault     string // Error for an invalid default value of a Bind field, field name, value and reason
+	IntegerBits            string // Reason for an integer value that is out of range, number of bits
+	IntegerBase            string // Reason for a value that is not an integer of the base set with Base, base
+	NotInteger             string // Reason for a value that is not an integer
+	NotNumber              string // Reason for a value that is not a number
+	NoPatternMatch         string // Reason for a value that does not match MatchRegex, regular expression
+	BelowMinimum           string // Reason for a value below Min, minimum
+	AboveMaximum           string // Reason for a value above Max, maximum
+	KeyValueFormat         string // Reason for a map option value that is not key=value
+	PathNotExist           string // Reason for a path that does not exist
+	PathIsDirectory        string // Reason for a path that is a directory
+	PathNotDirectory       string // Reason for a path that is not a directory
+	ParentNotExist         string // Reason for a path where the parent directory does not exist
+	ParentNotDirectory     string // Reason for a path where the parent is not a directory
+	RemoveNotSupported     string // Reason when values are removed from an option that is not a list or map option
+}
+
+// EnglishMessages is the default message catalog
+var EnglishMessages = Messages{
+	Usage:            "Usage:",
+	Options:          "Options:",
+	CommandOptions:   "%s options:",
+	InheritedOptions: "Options inherited from %s:",
+	HelpFullHint:     "Use %s all or --help-full to show command options",
+	CommandCategory:  "%s:",
+	Examples:         "Examples:",
+	NoHelpMatches:    "No commands or options match \"%s\"",
+
+	Required:     "(required)",
+	Preference:   "(*)",
+	Locked:       "(locked)",
+	Deprecated:   "(deprecated)",
+	Inherited:    "(inherited by subcommands)",
+	DefaultOn:    "(default ON)",
+	Default:      "(default %s)",
+	ExampleText:  "(e.g. %s)",
+	Aliases:      "(aliases %s)",
+	CurrentlySet: "(currently set from %s)",
+	Currently:    "(currently: %s from %s)",
+	Hidden:       "(hidden)",
+	NotSaved:     "(not saved)",
+	Range:        "(%s-%s)",
+	Min:          "(min %s)",
+	Max:          "(max %s)",
+	Matching:     "(matching %s)",
+
+	SourceDefault:     "default",
+	SourceOptionsFile: "saved options",
+	SourceCommandLine: "command line",
+	SourceProgram:     "application",
+	SourcePolicy:      "policy",
+
+	SaveOptionsHelp:     "Save (*) options to %s, or only the options of a command to a section of the file",
+	ShowOptionsHelp:     "Show options that would be saved, the saved file, the current value of all (*) options or the differences between the file and current values",
+	ExceptHelp:          "Leave options out of %s, the options file keeps their saved values",
+	ValidateOptionsHelp: "Check saved options for errors",
+	EditOptionsHelp:     "Edit saved options in $EDITOR, they are only saved if they are valid",
+	SaveProfileHelp:     "Save the command, options and arguments of this invocation as a named profile",
+	RunProfileHelp:      "Run a saved profile, options and arguments following it are added to the profile",
+	ShowConfigHelp:      "Show the current value of all options and where it was set from",
+	ProfileHelp:         "Load and save (*) options with a named profile in the options file",
+	ProfilesHelp:        "List the profiles in the options file",
+	VersionHelp:         "Show current version",
+	CheckUpdateHelp:     "Check if a newer version is available",
+
+	Warning:                "Warning: %s",
+	DuplicateOption:        "Option %s specified more than once, the last value is used",
+	DeprecatedOption:       "Option %s is deprecated",
+	DeprecatedOptionReason: "Option %s is deprecated, %s",
+	OptionsSaved:           "Options saved to %s",
+	UpdateAvailable:        "Version %s is available, current version is %s",
+	UpToDate:               "%s version %s is up to date",
+	ProfileSaved:           "Profile %s saved to %s",
+	NoProblems:             "No problems found in %s",
+	NoDifferences:          "No differences between %s and the current options",
+	NoChanges:              "No changes made to %s",
+	NoVersion:              "No version has been set",
+	DefaultCommand:         "(default command)",
+	ExplainCommand:         "Command: %s",
+	ExplainArguments:       "Arguments: %q",
+	ExplainRawArguments:    "Arguments following --: %q",
+	CommandOption:          ", %s option",
+	RecentNotSaved:         "Unable to save recent values (%s)",
+	ReloadFailed:           "Unable to reload options: %s",
+	NormalizedKey:          "option \"%s\" read as \"%s\"",
+	UnknownOptionIgnored:   "%s ignored",
+	SharedSensitiveFile:    "%s contains sensitive option \"%s\" but can be accessed by other users (permissions %04o), restrict it with chmod 600",
+
+	Error:                  "Error: %s",
+	InvalidOption:          "Invalid option %s",
+	InvalidValue:           "Invalid value set for option %s: \"%s\" (%s)",
+	DuplicateOptionError:   "Option %s can only be specified once",
+	ExpectedFormat:         ", expected %s",
+	ExpectedExample:        ", e.g. %s",
+	MissingRequiredOption:  "Missing required option %s",
+	MissingRequiredOptions: "Missing required options %s",
+	MissingOneOf:           "Missing required option, one of %s",
+	OnlyOneOf:              "Options %s cannot be used together",
+	ExceptWithoutSave:      "%s can only be used together with %s",
+	UnknownProfile:         "Profile %s does not exist in the options file",
+	UpdateCheckFailed:      "Unable to check for updates (%s)",
+	MissingCommand:         "Missing required command",
+	MissingSubCommand:      "Missing required command for %s",
+	InvalidCommand:         "%s is not a valid command",
+	CommandNotAvailable:    "Command %s is not available on %s",
+	OptionNotAvailable:     "Option %s is not available on %s",
+	CompactUsage:           "Usage: %s",
+	DidYouMean:             "Did you mean %s?",
+	HelpHint:               "Use %s for help",
+	ValueFileError:         "Unable to read value for option %s: %s",
+	UnknownRequiredOption:  "Command %s requires option %s that does not exist",
+	ProblemsFound:          "%d problem(s) found in %s",
+	EditProblemsFound:      "%d problem(s) found, options not saved, edited file kept as %s",
+	InvalidEditor:          "Invalid editor \"%s\" (%s)",
+	NoEditor:               "No editor found, set the EDITOR environment variable",
+	EditorFailed:           "Unable to run editor %s (%s)",
+	InvalidShowOptionsMode: "Invalid mode for %s: %s (use file, effective or diff)",
+	NoOptionsFile:          "No options file has been set",
+	OptionLockedError:      "Option %s is locked by policy",
+	PluginFailed:           "Unable to run plugin %s (%s)",
+	PluginExited:           "Plugin %s exited with code %d",
+	ElevationRequired:      "Command %s requires %s privileges",
+	TimedOut:               "Command timed out after %s",
+	SaveOptionFailed:       "Unable to save option %s (%s)",
+	FileNotObject:          "options file must contain a JSON object",
+	FileInvalidVersion:     "invalid options file version %v",
+	FileNewerVersion:       "options file version %d is newer than supported version %d",
+	FileUnknownOption:      "unknown option \"%s\"",
+	FileNotPreference:      "option \"%s\" is not a preference option",
+	FileInvalidValue:       "invalid value for option \"%s\" (%s)%s",
+	FileOptionLocked:       "option \"%s\" is locked by policy",
+	ObjectNotSupported:     "object values are only supported by map options and values implementing json.Unmarshaler",
+	ObjectValueNotString:   "object value %v of key %s is not a string",
+	ArrayValueNotString:    "array element %v is not a string",
+	MigrationFailed:        "Unable to migrate options from version %d (%s)",
+	NotPreference:          "%s is not a preference",
+	MissingConfigCommand:   "Missing config command, use get, set, unset or list",
+	ConfigSingleValue:      "Option %s takes a single value",
+	ConfigArguments:        "Wrong number of arguments for config %s",
+	UnknownConfigCommand:   "Unknown config command %s, use get, set, unset or list",
+	NestedResponseFiles:    "Too many nested response files",
+	ResponseFileError:      "Unable to read response file %s (%s)",
+	RunProfilesError:       "Unable to read run profiles from %s (%s)",
+	NestedRunProfiles:      "Too many nested run profiles",
+	UnknownRunProfile:      "Run profile %s does not exist",
+	InvalidVersionFormat:   "Invalid version format \"%s\"",
+	InvalidCommandDefault:  "Invalid default \"%s\" of option %s for command %s (%s)",
+	InvalidInheritedValue:  "Invalid value \"%s\" of option %s inherited by command %s (%s)",
+	SecretValue:            "Option %s is a secret and can only be read through its Secret",
+	WrongValueType:         "Option %s holds %T, not %s",
+	InvalidRange:           "Start %d of range is greater than end %d",
+	UnsupportedShell:       "Unsupported shell %s, use sh, bash, zsh, fish, powershell or cmd",
+	UnexpectedEndOfLine:    "Unexpected end of line after \\",
+	UnexpectedEndOfValue:   "Unexpected end of value after \\",
+	UnterminatedQuote:      "Unterminated quote %c",
+	BindNotStruct:          "Bind requires a pointer to a struct",
+	BindUnexported:         "Unable to bind unexported field %s",
+	BindInvalidFlags:       "Invalid flags for field %s (%s)",
+	BindUnknownFlag:        "unknown flag %s",
+	BindUnsupportedType:    "Unable to bind field %s of unsupported type %s",
+	BindInvalidDefault:     "Invalid default value for field %s: \"%s\" (%s)",
+	IntegerBits:            "value does not fit in %d bits",
+	IntegerBase:            "value is not a base %d integer",
+	NotInteger:             "value is not an integer",
+	NotNumber:              "value is not a number",
+	NoPatternMatch:         "value must match %s",
+	BelowMinimum:           "value must be at least %s",
+	AboveMaximum:           "value must be at most %s",
+	KeyValueFormat:         "value must be in the format key=value",
+	PathNotExist:           "path does not exist",
+	PathIsDirectory:        "path is a directory",
+	PathNotDirectory:       "path is not a directory",
+	ParentNotExist:         "parent directory does not exist",
+	ParentNotDirectory:     "parent is not a directory",
+	RemoveNotSupported:     "values can only be removed from list and map options",
+}
+
+var messages = EnglishMessages
+
+// SetMessages sets the message catalog used for help, warnings and errors, making it possible to localize the
+// generated text. Fields left blank use the text of EnglishMessages.
+//
+//	m := cmdparse.EnglishMessages
+//	m.Usage = "Användning:"
+//	m.Options = "Flaggor:"
+//	m.InvalidOption = "Ogiltig flagga %s"
+//	cmdparse.SetMessages(m)
+func SetMessages(m Messages) {
+	v := reflect.ValueOf(&m).Elem()
+	english := reflect.ValueOf(EnglishMessages)
+	for i := 0; i < v.NumField(); i++ {
+		if v.Field(i).String() == "" {
+			v.Field(i).Set(english.Field(i))
+		}
+	}
+	messages = m
+}
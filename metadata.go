@@ -0,0 +1,90 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+)
+
+// metadataCommand is the hidden command that describes the capabilities of the program to external tooling
+const metadataCommand = "__metadata"
+
+const (
+	metadataVersion    = 1 // Version of the __metadata output
+	completionProtocol = 1 // Version of the __complete protocol, see CompleteWith
+)
+
+// metadataInfo is printed as JSON by the hidden __metadata command. Completion installers and documentation
+// generators can run
+//
+//	mytool __metadata
+//
+// to find out what a program built with this package supports, before relying on __complete or the options file.
+// SpecDigest changes when commands or options are added, removed or redefined, so generated documentation or
+// completion scripts can be refreshed only when needed.
+type metadataInfo struct {
+	MetadataVersion    int      `json:"metadataVersion"`    // Version of this format
+	Name               string   `json:"name"`               // Name of the program
+	Version            string   `json:"version,omitempty"`  // Version of the program set with SetVersion
+	CompletionProtocol int      `json:"completionProtocol"` // Version of the __complete protocol
+	ConfigFormats      []string `json:"configFormats"`      // Formats of the options and policy files
+	Features           []string `json:"features"`           // Optional features that are enabled
+	SpecDigest         string   `json:"specDigest"`         // Digest of the definition of all commands and options
+}
+
+// printMetadata prints the metadata of the program as JSON
+func printMetadata() error {
+	info := metadataInfo{MetadataVersion: metadataVersion, Name: commandName, Version: Version,
+		CompletionProtocol: completionProtocol, ConfigFormats: []string{"json"}, Features: []string{"complete"}}
+	for _, f := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"options-file", OptionsFile != ""},
+		{"options-profiles", OptionsFile != "" && optionsProfiles},
+		{"run-profiles", RunProfilesFile != ""},
+		{"response-files", AllowResponseFiles},
+		{"policy-file", PolicyFile != ""},
+		{"config-command", hasConfigCommand()},
+		{"version", hasVersion()},
+		{"check-update", updateChecker != nil},
+		{"help-search", len(helpFlags) > 0},
+	} {
+		if f.enabled {
+			info.Features = append(info.Features, f.name)
+		}
+	}
+	info.SpecDigest = specDigest()
+
+	js, err := json.MarshalIndent(info, "", "\t")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(output, string(js))
+	return nil
+}
+
+// specDigest returns the SHA-256 digest of the declared commands and options in the order they were added. Only the
+// definition is included, so values, defaults computed by DefaultFunc and the help sort order do not change it.
+func specDigest() string {
+	h := sha256.New()
+	for _, c := range commandList {
+		parent := ""
+		if c.parent != nil {
+			parent = c.parent.Command
+		}
+		fmt.Fprintf(h, "command %q %q\n", c.Command, parent)
+	}
+	for _, o := range optionList {
+		def := o.Default
+		if o.defaultFunc != nil {
+			def = ""
+		}
+		fmt.Fprintf(h, "option %q %q %q %T %d %q\n", o.Name, o.Group, o.Format, o.Value, o.Flags, def)
+	}
+	return fmt.Sprintf("sha256:%x", h.Sum(nil))
+}
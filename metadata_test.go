@@ -0,0 +1,35 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestSpecDigest(t *testing.T) {
+	Reset()
+	var workers int64
+	var name string
+	Command("run", "", func() {})
+	StringOption("name", "", "<name>", "Name", &name, 0)
+	calls := 0
+	IntOption("workers", "", "<n>", "Workers", &workers, 0).DefaultFunc(func() string {
+		calls++
+		return strconv.Itoa(calls)
+	})
+	digest := specDigest()
+
+	resolveDefaults()
+	SetOptionSort(SortAlphabetical)
+	name = "changed"
+	if d := specDigest(); d != digest {
+		t.Errorf("digest changed from %s to %s without changing the definition", digest, d)
+	}
+	StringOption("extra", "", "", "Extra", &name, 0)
+	if d := specDigest(); d == digest {
+		t.Error("digest did not change when an option was added")
+	}
+}
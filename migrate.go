@@ -0,0 +1,53 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import "fmt"
+
+// OptionsVersion is the schema version of the options file used by the application. When it is set above zero, the
+// version is stored in the options file on save, and migrations registered with RegisterMigration are applied when
+// an options file with an older version is loaded. Options files without a version are treated as version 0.
+var OptionsVersion int
+
+const optionsVersionKey = "_version" // Key of the schema version in the options file
+
+var migrations = make(map[int]func(options map[string]interface{}) error)
+
+// RegisterMigration adds a function that migrates the options file from the specified version to the next one.
+// The function receives all keys and JSON decoded values of the options file and can rename, transform or delete
+// them. Migrations are applied in order when loading, the file itself is updated the next time options are saved.
+//
+//	cmdparse.OptionsVersion = 1
+//	cmdparse.RegisterMigration(0, func(options map[string]interface{}) error {
+//	  if v, ok := options["srv"]; ok {
+//	    options["server"] = v
+//	    delete(options, "srv")
+//	  }
+//	  return nil
+//	})
+func RegisterMigration(fromVersion int, migrate func(options map[string]interface{}) error) {
+	migrations[fromVersion] = migrate
+}
+
+// migrateOptions applies all migrations needed to bring the options map up to OptionsVersion
+func migrateOptions(options map[string]interface{}) error {
+	version := 0
+	if v, ok := options[optionsVersionKey]; ok {
+		f, ok := v.(float64)
+		if !ok || f != float64(int(f)) {
+			return fmt.Errorf(messages.FileInvalidVersion, v)
+		}
+		version = int(f)
+		delete(options, optionsVersionKey)
+	}
+	for ; version < OptionsVersion; version++ {
+		if migrate, ok := migrations[version]; ok {
+			if err := migrate(options); err != nil {
+				return fmt.Errorf(messages.MigrationFailed, version, err.Error())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+const recentKey = "_recent" // Key of the recent values of MRU options in the options file
+
+// MRU keeps the last n distinct values that the option has been given on the commandline in the options file, most
+// recent first. The values are returned by RecentValues and offered by shell completion, which makes it easy to pick
+// a recent server or profile again. Values of Sensitive options are never kept. MRU requires OptionsFile to be set.
+//
+//	cmdparse.StringOption("server", "", "<host>", "Server to connect to", &server, cmdparse.Standard).MRU(5)
+func (c *CmdOption) MRU(n int) *CmdOption {
+	c.mru = n
+	return c
+}
+
+// RecentValues returns the recent values of an MRU option, most recent first, or nil if there is no such option
+//
+//	for _, s := range cmdparse.RecentValues("server") {
+//	  fmt.Println(s)
+//	}
+func RecentValues(name string) []string {
+	o := findOption(name)
+	if o == nil {
+		return nil
+	}
+	return append([]string{}, o.recent...)
+}
+
+// loadRecent sets the recent values of MRU options from the value of the recent key in the options file
+func loadRecent(value interface{}) {
+	recent, _ := value.(map[string]interface{})
+	for _, o := range optionList {
+		list, _ := recent[o.key()].([]interface{})
+		o.recent = nil
+		for _, v := range list {
+			if s, ok := v.(string); ok && o.mru > 0 && len(o.recent) < o.mru {
+				o.recent = append(o.recent, s)
+			}
+		}
+	}
+}
+
+// recentValues returns the recent values of all MRU options by options file key
+func recentValues() map[string][]string {
+	recent := make(map[string][]string)
+	for _, o := range optionList {
+		if o.mru > 0 && len(o.recent) > 0 {
+			recent[o.key()] = o.recent
+		}
+	}
+	return recent
+}
+
+// updateRecent adds the values of MRU options set on the commandline to their recent values and saves them to the
+// options file, leaving the rest of the file as it is
+func updateRecent() error {
+	var changed bool
+	for _, o := range optionList {
+		if o.mru == 0 || o.source != SourceCommandLine || o.Flags&Sensitive > 0 {
+			continue
+		}
+		value := o.Value.String()
+		if len(o.recent) > 0 && o.recent[0] == value {
+			continue
+		}
+		list := []string{value}
+		for _, r := range o.recent {
+			if r != value && len(list) < o.mru {
+				list = append(list, r)
+			}
+		}
+		o.recent = list
+		changed = true
+	}
+	if !changed || OptionsFile == "" {
+		return nil
+	}
+
+	optionMap := make(map[string]interface{})
+	data, err := optionsStore.Load(OptionsFile)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	} else if err == nil {
+		entries, offset, err := decodeEntries(data)
+		if err != nil {
+			return fmt.Errorf("%s: %s", filePosition(OptionsFile, data, offset), err.Error())
+		}
+		for _, e := range entries {
+			optionMap[e.key] = e.value
+		}
+	}
+	optionMap[recentKey] = recentValues()
+	jsonData, err := json.MarshalIndent(optionMap, "", "\t")
+	if err != nil {
+		return err
+	}
+	return optionsStore.Save(OptionsFile, jsonData, optionsFilePerm())
+}
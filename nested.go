@@ -0,0 +1,46 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+var nestedGroups bool
+
+// SetNestedGroups saves the options of every command group to a section of the options file named after the command,
+// instead of a single flat list of options. Command options are then always qualified by the command name, also in
+// ExportEnv and the config command, so options of different commands never collide even if they share a name. Files
+// saved with flat keys are still loaded.
+//
+//	cmdparse.SetNestedGroups(true)
+//
+//	{
+//	  "server": "localhost",
+//	  "copy": {
+//	    "ignore": ["*.tmp"]
+//	  }
+//	}
+func SetNestedGroups(nested bool) {
+	nestedGroups = nested
+	invalidateIndex()
+}
+
+// nestOptions moves the options of command groups in an options map to sections named after the command, when
+// SetNestedGroups is enabled
+func nestOptions(optionMap map[string]interface{}) {
+	if !nestedGroups {
+		return
+	}
+	for key, v := range optionMap {
+		o := findRegisteredOption(key)
+		if o == nil || o.Group == "" || findRegisteredOption(o.Group) != nil {
+			continue // A global option named like the command would take the place of the section
+		}
+		section, ok := optionMap[o.Group].(map[string]interface{})
+		if !ok {
+			section = make(map[string]interface{})
+			optionMap[o.Group] = section
+		}
+		section[o.Name] = v
+		delete(optionMap, key)
+	}
+}
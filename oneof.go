@@ -0,0 +1,61 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// optionGroup is a set of options of which at least one, or exactly one, must be set
+type optionGroup struct {
+	names []string
+	exact bool
+}
+
+var requiredGroups []optionGroup
+
+// RequireOneOf makes parsing fail unless at least one of the named options is set, on the commandline or in the
+// options file. The check is made when a command is run and only if at least one of the options is available to
+// the command, so a group of command options is only checked for that command.
+//
+//	cmdparse.StringOption("token", "", "<token>", "Access token", &token, cmdparse.Standard)
+//	cmdparse.StringOption("user", "", "<name>", "User name", &user, cmdparse.Standard)
+//	cmdparse.RequireOneOf("token", "user")
+func RequireOneOf(names ...string) {
+	requiredGroups = append(requiredGroups, optionGroup{names: names})
+}
+
+// RequireExactlyOneOf is like RequireOneOf but also makes parsing fail if more than one of the named options is set.
+//
+//	cmdparse.RequireExactlyOneOf("token", "user")
+func RequireExactlyOneOf(names ...string) {
+	requiredGroups = append(requiredGroups, optionGroup{names: names, exact: true})
+}
+
+// checkGroups returns an error for the first option group that is not satisfied when command is run
+func checkGroups(command *CmdCommand) error {
+	for _, g := range requiredGroups {
+		var names, set []string
+		for _, name := range g.names {
+			o := findScopedOption(name, command)
+			if o == nil {
+				continue
+			}
+			names = append(names, displayPrefix+o.Name)
+			if o.changed() {
+				set = append(set, displayPrefix+o.Name)
+			}
+		}
+		if len(names) == 0 {
+			continue
+		} else if len(set) == 0 {
+			return fmt.Errorf(messages.MissingOneOf, strings.Join(names, ", "))
+		} else if g.exact && len(set) > 1 {
+			return fmt.Errorf(messages.OnlyOneOf, strings.Join(set, ", "))
+		}
+	}
+	return nil
+}
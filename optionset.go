@@ -0,0 +1,41 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+// OptionSet bundles related options, like the certificate, key and CA options of a TLS connection, so that they can
+// be attached to several commands instead of being copied to each of them.
+type OptionSet struct {
+	Name string                          // Name of the set, like "TLS options"
+	add  func(prefix string, cmd string) // adds the options of the set
+}
+
+// NewOptionSet returns an option set with the specified name and a function that adds its options. The function is
+// called by Attach with the name prefix and command group that the options are added with. Options of a set attached
+// to several commands can share variables, as only one command is run.
+//
+//	tls := cmdparse.NewOptionSet("TLS options", func(prefix string, cmd string) {
+//	  cmdparse.StringOption(prefix+"cert", cmd, "<file>", "Client certificate", &tlsCert, cmdparse.Standard)
+//	  cmdparse.StringOption(prefix+"key", cmd, "<file>", "Client key", &tlsKey, cmdparse.Standard)
+//	  cmdparse.StringOption(prefix+"ca", cmd, "<file>", "Certificate authority", &tlsCA, cmdparse.Standard)
+//	  cmdparse.BoolOption(prefix+"insecure", cmd, "Skip certificate verification", &tlsInsecure, cmdparse.Standard)
+//	})
+//	tls.Attach("push", "")
+//	tls.Attach("pull", "")
+//	tls.Attach("mirror", "upstream-")
+func NewOptionSet(name string, add func(prefix string, cmd string)) *OptionSet {
+	return &OptionSet{Name: name, add: add}
+}
+
+// Attach adds the options of the set to a command group, blank for global options, with names starting with prefix.
+// The added options are returned and have an "option-set" annotation with the name of the set.
+func (s *OptionSet) Attach(cmd string, prefix string) []*CmdOption {
+	first := len(optionList)
+	s.add(prefix, cmd)
+	var added []*CmdOption
+	for _, o := range optionList[first:] {
+		added = append(added, o.Annotate("option-set", s.Name))
+	}
+	return added
+}
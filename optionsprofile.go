@@ -0,0 +1,197 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+)
+
+const profilesKey = "_profiles" // Key of the named profiles in the options file
+
+var optionsProfiles bool
+var activeProfile string // Profile selected with -profile, blank for the options at the top of the file
+
+// SetOptionsProfiles enables named profiles in the options file, selected with -profile=<name>. A profile is a nested
+// object of Preference options that are loaded on top of the options at the top of the file, and -saveoptions with a
+// profile selected saves to the profile instead. -profiles lists the profiles in the file. Profiles require OptionsFile
+// to be set.
+//
+//	cmdparse.SetOptionsProfiles(true)
+//
+//	{
+//	  "server": "localhost",
+//	  "_profiles": {
+//	    "work": {
+//	      "server": "build.example.com",
+//	      "retries": 3
+//	    }
+//	  }
+//	}
+//
+//	mytool -profile=work push .
+func SetOptionsProfiles(enable bool) {
+	optionsProfiles = enable
+}
+
+// Profile returns the name of the profile selected with -profile, or a blank string if no profile is selected
+func Profile() string {
+	return activeProfile
+}
+
+// Profiles returns the sorted names of the profiles in the options file
+func Profiles() ([]string, error) {
+	profiles, err := storedProfiles()
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for name := range profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// listProfiles prints the profiles in the options file, marking the selected profile
+func listProfiles() error {
+	names, err := Profiles()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if name == activeProfile {
+			fmt.Fprintln(output, "* "+name)
+		} else {
+			fmt.Fprintln(output, "  "+name)
+		}
+	}
+	return nil
+}
+
+// checkProfile returns an error if the selected profile does not exist in the options file, unless it is being saved
+func checkProfile(saving bool) error {
+	if activeProfile == "" || saving {
+		return nil
+	}
+	profiles, err := storedProfiles()
+	if err != nil {
+		return err
+	} else if _, ok := profiles[activeProfile]; !ok {
+		return fmt.Errorf(messages.UnknownProfile, activeProfile)
+	}
+	return nil
+}
+
+// storedProfiles returns the profiles in the options file, an empty map if there is no options file
+func storedProfiles() (map[string]interface{}, error) {
+	data, err := optionsStore.Load(OptionsFile)
+	if os.IsNotExist(err) {
+		return make(map[string]interface{}), nil
+	} else if err != nil {
+		return nil, err
+	}
+	entries, offset, err := decodeEntries(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", filePosition(OptionsFile, data, offset), err.Error())
+	}
+	for _, e := range entries {
+		if profiles, ok := e.value.(map[string]interface{}); ok && e.key == profilesKey {
+			return profiles, nil
+		}
+	}
+	return make(map[string]interface{}), nil
+}
+
+// profileEntries returns the options of the selected profile in the profiles entry of the options file
+func profileEntries(e optionsEntry) []optionsEntry {
+	profiles, _ := e.value.(map[string]interface{})
+	if profile, ok := profiles[activeProfile].(map[string]interface{}); ok && activeProfile != "" {
+		return expandEntries(profile, e.offset)
+	}
+	return nil
+}
+
+// expandEntries returns the options of a JSON object in key order, with the options in command sections as separate
+// entries
+func expandEntries(m map[string]interface{}, offset int64) []optionsEntry {
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var entries []optionsEntry
+	for _, key := range keys {
+		entries = append(entries, expandEntry(optionsEntry{key: key, value: m[key], offset: offset})...)
+	}
+	return entries
+}
+
+// saveProfileOptions replaces the selected profile in the options file with the current options, or only the options
+// of a command group if cmd is set, leaving the rest of the file as it is
+func saveProfileOptions(name string, cmd string) (string, error) {
+	if cmd != "" && findCommand(cmd) == nil {
+		return "", fmt.Errorf(messages.InvalidCommand, cmd)
+	}
+	optionMap := make(map[string]interface{})
+	data, err := optionsStore.Load(name)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	} else if err == nil {
+		entries, offset, err := decodeEntries(data)
+		if err != nil {
+			return "", fmt.Errorf("%s: %s", filePosition(name, data, offset), err.Error())
+		}
+		for _, e := range entries {
+			optionMap[e.key] = e.value
+		}
+	}
+	profiles, _ := optionMap[profilesKey].(map[string]interface{})
+	if profiles == nil {
+		profiles = make(map[string]interface{})
+	}
+
+	profile := make(map[string]interface{})
+	if old, ok := profiles[activeProfile].(map[string]interface{}); ok && cmd != "" {
+		for _, e := range expandEntries(old, 0) {
+			if o := findRegisteredOption(e.key); o == nil || o.Group != cmd {
+				profile[e.key] = e.value
+			}
+		}
+	}
+	for _, o := range optionList {
+		if (cmd == "" || o.Group == cmd) && o.persisted() {
+			if err := o.doSave(); err != nil {
+				return "", err
+			}
+			value, err := o.savedValue(false)
+			if err != nil {
+				return "", err
+			}
+			profile[o.key()] = value
+		}
+	}
+	nestOptions(profile)
+	profiles[activeProfile] = profile
+	optionMap[profilesKey] = profiles
+	if OptionsVersion > 0 {
+		optionMap[optionsVersionKey] = OptionsVersion
+	}
+	jsonData, err := json.MarshalIndent(optionMap, "", "\t")
+	if err != nil {
+		return "", err
+	}
+	if err := optionsStore.Save(name, jsonData, optionsFilePerm()); err != nil {
+		return "", err
+	}
+	for _, o := range optionList {
+		if (cmd == "" || o.Group == cmd) && o.persisted() {
+			emitEvent(OptionSaved, o)
+		}
+	}
+	return string(jsonData), nil
+}
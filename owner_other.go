@@ -0,0 +1,18 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd
+
+package cmdparser
+
+import "os"
+
+// keepOwner does nothing, files do not have a Unix owner on this platform
+func keepOwner(name string, info os.FileInfo) {}
+
+// sharedPerm returns false, file permissions are not checked on this platform
+func sharedPerm(name string) (os.FileMode, bool) {
+	return 0, false
+}
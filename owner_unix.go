@@ -0,0 +1,30 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package cmdparser
+
+import (
+	"os"
+	"syscall"
+)
+
+// keepOwner gives the file name the owner and group of the file described by info, which only succeeds when
+// running as root, for example when a tool run with sudo overwrites the options file of the user
+func keepOwner(name string, info os.FileInfo) {
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		os.Chown(name, int(stat.Uid), int(stat.Gid))
+	}
+}
+
+// sharedPerm returns the permissions of the file name if it can be read or written by other users than the owner
+func sharedPerm(name string) (os.FileMode, bool) {
+	info, err := os.Stat(name)
+	if err != nil || info.Mode().Perm()&0077 == 0 {
+		return 0, false
+	}
+	return info.Mode().Perm(), true
+}
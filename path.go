@@ -0,0 +1,77 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+)
+
+// PathCheck selects what PathOption checks about a path when it is set
+type PathCheck int
+
+// Checks of PathOption values
+const (
+	ExistingFile    PathCheck = 1 << iota // Path must be an existing file that is not a directory
+	ExistingDir                           // Path must be an existing directory
+	CreatableParent                       // Folder that the path is in must exist, so that the path can be created
+)
+
+type pathOption struct {
+	variable *string
+	checks   PathCheck
+}
+
+func (p pathOption) String() string   { return *p.variable }
+func (p pathOption) Reset()           { *p.variable = "" }
+func (p pathOption) Get() interface{} { return *p.variable }
+func (p pathOption) Set(s string) error {
+	if err := p.check(s); err != nil {
+		return err
+	}
+	*p.variable = s
+	return nil
+}
+
+// check returns an error describing why the path fails the checks
+func (p pathOption) check(path string) error {
+	if p.checks&(ExistingFile|ExistingDir) != 0 {
+		info, err := os.Stat(path)
+		if os.IsNotExist(err) {
+			return errors.New(messages.PathNotExist)
+		} else if err != nil {
+			return err
+		}
+		if info.IsDir() && p.checks&ExistingDir == 0 {
+			return errors.New(messages.PathIsDirectory)
+		} else if !info.IsDir() && p.checks&ExistingFile == 0 {
+			return errors.New(messages.PathNotDirectory)
+		}
+	}
+	if p.checks&CreatableParent != 0 {
+		info, err := os.Stat(filepath.Dir(path))
+		if os.IsNotExist(err) {
+			return errors.New(messages.ParentNotExist)
+		} else if err != nil {
+			return err
+		} else if !info.IsDir() {
+			return errors.New(messages.ParentNotDirectory)
+		}
+	}
+	return nil
+}
+
+// PathOption adds a string option for a file or directory path with the specified name, command group, help text,
+// variable pointer, checks and flags. The checks are done when the option is set, from the commandline or options
+// file, so a missing or wrong kind of path fails with a friendly error before the command function uses it.
+// ExistingFile|ExistingDir accepts any existing path.
+//
+//	var input, output string
+//	cmdparse.PathOption("input", "convert", "<file>", "File to convert", &input, cmdparse.ExistingFile, cmdparse.Required)
+//	cmdparse.PathOption("output", "convert", "<file>", "Converted file", &output, cmdparse.CreatableParent, cmdparse.Standard)
+func PathOption(name string, cmd string, format string, help string, variable *string, checks PathCheck, flags int) *CmdOption {
+	return addOption(name, cmd, format, help, pathOption{variable, checks}, flags)
+}
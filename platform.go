@@ -0,0 +1,49 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import "runtime"
+
+// Platforms makes the option available only on the specified operating systems, named like runtime.GOOS. On other
+// systems the option is hidden from help and specifying it on the commandline is an error.
+//
+//	cmdparse.BoolOption("xattrs", "", "Preserve extended attributes", &xattrs, cmdparse.Standard).Platforms("linux", "darwin")
+func (c *CmdOption) Platforms(goos ...string) *CmdOption {
+	c.platforms = goos
+	if !c.available() {
+		c.Flags |= Hidden
+	}
+	return c
+}
+
+// Platforms makes the command available only on the specified operating systems, named like runtime.GOOS. On other
+// systems the command, its child commands and its options are left out of help and running it is an error.
+//
+//	cmdparse.Command("service", "install|uninstall", service).Platforms("windows")
+func (c *CmdCommand) Platforms(goos ...string) *CmdCommand {
+	c.platforms = goos
+	return c
+}
+
+// available returns true if the option and its command are available on this operating system
+func (c *CmdOption) available() bool {
+	if len(c.platforms) > 0 && !contains(c.platforms, runtime.GOOS) {
+		return false
+	}
+	if g := findCommand(c.Group); c.Group != "" && g != nil {
+		return g.available()
+	}
+	return true
+}
+
+// available returns true if the command and its parents are available on this operating system
+func (c *CmdCommand) available() bool {
+	for ; c != nil; c = c.parent {
+		if len(c.platforms) > 0 && !contains(c.platforms, runtime.GOOS) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,112 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// DiscoverPlugins scans the folders in PATH for executables named <prefix>-<command> and adds each of them as a
+// command, so that third parties can extend the tool the same way as git and kubectl plugins. A blank prefix uses
+// the program name. Commands that are already added are not replaced and the first executable found in PATH wins.
+//
+// When a plugin command is run, the executable is started with any options that are not registered followed by the
+// command arguments, including arguments after --. The current option values are exported to its environment like
+// with ExportEnv, using the upper case prefix followed by _, like MYTOOL_VERBOSE=true, but Masked and Sensitive options
+// are left out as the plugin is not necessarily trusted. The plugin writes to the Output of the parse that runs it,
+// so that its output reaches the caller of ParseArgs and ServeCommands clients. If the plugin fails, the parse
+// functions return a *PluginExitError with its exit code, which Execute returns as the exit code of the program.
+// Plugin commands have no Function, use Run to start one directly. The path of the executable is available as the
+// "plugin" annotation.
+//
+//	cmdparse.DiscoverPlugins("mytool")
+//
+//	mytool deploy -target=prod    (runs mytool-deploy -target=prod)
+func DiscoverPlugins(prefix string) []*CmdCommand {
+	if prefix == "" {
+		prefix = commandName
+	}
+	var added []*CmdCommand
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		files, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, f := range files {
+			name, ok := pluginName(prefix, f)
+			if !ok || findCommand(name) != nil {
+				continue
+			}
+			added = append(added, addPlugin(prefix, name, filepath.Join(dir, f.Name())))
+		}
+	}
+	return added
+}
+
+// pluginName returns the command name of a plugin executable, or false if the file is not a plugin
+func pluginName(prefix string, f os.FileInfo) (string, bool) {
+	name := f.Name()
+	if f.IsDir() || !strings.HasPrefix(name, prefix+"-") {
+		return "", false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(name))
+		if ext == "" || !contains(strings.Split(strings.ToLower(os.Getenv("PATHEXT")), ";"), ext) {
+			return "", false
+		}
+		name = name[:len(name)-len(ext)]
+	} else if f.Mode()&0111 == 0 {
+		return "", false
+	}
+	name = name[len(prefix)+1:]
+	return name, name != "" && !strings.ContainsAny(name, " \t")
+}
+
+// PluginExitError is returned by the parse functions when a plugin command added by DiscoverPlugins exits with a
+// non-zero exit code. The default error handler of Execute returns the exit code without printing anything, as the
+// plugin reports its own errors.
+type PluginExitError struct {
+	Plugin string // Path of the plugin executable
+	Code   int    // Exit code of the plugin
+}
+
+func (e *PluginExitError) Error() string {
+	return fmt.Sprintf(messages.PluginExited, e.Plugin, e.Code)
+}
+
+func addPlugin(prefix string, name string, path string) *CmdCommand {
+	c := Command(name, "[<arguments>]", nil)
+	c.run = func(ctx context.Context) error {
+		args, w := Args, Output()
+		if r := ResultFromContext(ctx); r != nil {
+			args, w = append(append([]string{}, r.Unknown...), r.Args...), r.Output
+		}
+		cmd := exec.Command(path, args...)
+		cmd.Env = append(os.Environ(), exportEnv(envName("", prefix)+"_", false)...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, w, os.Stderr
+		if w != os.Stdout {
+			// Errors go to the same place as the output when that is not the terminal, like a ServeCommands client
+			cmd.Stderr = w
+		}
+		err := cmd.Run()
+		if e, ok := err.(*exec.ExitError); ok {
+			return &PluginExitError{Plugin: path, Code: e.ExitCode()}
+		} else if err != nil {
+			return fmt.Errorf(messages.PluginFailed, path, err.Error())
+		}
+		return nil
+	}
+	c.OnUnknownOption(func(option, value string) error {
+		return nil // Passed on to the plugin through ParseResult.Unknown
+	})
+	return c.Annotate("plugin", path)
+}
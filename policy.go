@@ -0,0 +1,62 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"fmt"
+	"os"
+)
+
+// PolicyFile sets the filename (with full path) of a system wide policy file for managed deployments. The policy
+// file has the same JSON format as the options file and is loaded before it. Every option in the policy file is set
+// and Locked, so that trying to change it from the options file or commandline fails with an error. Options in the
+// policy file do not have to be Preference options.
+//
+//	cmdparse.PolicyFile = "/etc/mytool/policy.json"
+var PolicyFile string
+
+// loadPolicy sets and locks all options found in the policy file, a missing policy file is not an error
+func loadPolicy(name string) error {
+	data, err := optionsStore.Load(name)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	entries, offset, err := decodeOptions(data)
+	if err != nil {
+		return fmt.Errorf("%s: %s", filePosition(name, data, offset), err.Error())
+	}
+	for _, e := range entries {
+		o := findRegisteredOption(e.key)
+		if o == nil {
+			warn(WarningUnknownOption, e.key, messages.UnknownOptionIgnored, fmt.Sprintf("%s: "+messages.FileUnknownOption, filePosition(name, data, e.offset), e.key))
+			continue
+		}
+		if err := setJSONValue(o, e.value); err != nil {
+			return fmt.Errorf("%s: "+messages.FileInvalidValue, filePosition(name, data, e.offset), o.Name, err.Error(), o.expectation())
+		}
+		o.source = SourcePolicy
+		o.Flags |= Locked
+		emitEvent(OptionLoaded, o)
+		o.traceSet(0)
+		o.doChange()
+	}
+	return nil
+}
+
+// lockedError is returned when trying to change a locked option
+type lockedError struct {
+	name string
+}
+
+func (e *lockedError) Error() string {
+	return fmt.Sprintf(messages.OptionLockedError, displayPrefix+e.name)
+}
+
+func (c *CmdOption) lockedError() error {
+	return &lockedError{name: c.Name}
+}
@@ -0,0 +1,60 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"sort"
+	"strings"
+)
+
+var optionPrefixes = []string{"-"} // Prefixes that start an option, longest first
+
+// SetOptionPrefixes sets the prefixes that start an option on the commandline, replacing the default "-". The
+// longest matching prefix is used, so SetOptionPrefixes("--", "-") accepts both -name and --name. An empty prefix
+// accepts registered option names without prefix, other arguments are then left for the command. Built-in flags
+// like -saveoptions use the same prefixes, while help flags are set with SetHelpFlags and -- always stops option
+// parsing. Help shows options with the first prefix.
+//
+//	cmdparse.SetOptionPrefixes("+")
+//
+//	mytool +verbose +server=localhost copy a b
+func SetOptionPrefixes(prefixes ...string) {
+	optionPrefixes = append([]string{}, prefixes...)
+	sort.SliceStable(optionPrefixes, func(i, j int) bool {
+		return len(optionPrefixes[i]) > len(optionPrefixes[j])
+	})
+	if len(prefixes) > 0 {
+		displayPrefix = prefixes[0]
+	}
+}
+
+var displayPrefix = "-" // Prefix used when showing option names in help
+
+// optionName returns the argument without its option prefix and true if the argument is an option
+func optionName(arg string) (string, bool) {
+	for _, p := range optionPrefixes {
+		if p == "" {
+			if arg != "" && findOption(strings.SplitN(arg, "=", 2)[0]) != nil {
+				return arg, true
+			}
+		} else if strings.HasPrefix(arg, p) && len(arg) > len(p) {
+			return arg[len(p):], true
+		}
+	}
+	return "", false
+}
+
+// builtinFlag returns true if the argument is the named built-in flag, together with the value following = if any
+func builtinFlag(arg string, name string) (string, bool) {
+	flag, ok := optionName(arg)
+	if !ok {
+		return "", false
+	} else if flag == name {
+		return "", true
+	} else if strings.HasPrefix(flag, name+"=") {
+		return flag[len(name)+1:], true
+	}
+	return "", false
+}
@@ -0,0 +1,57 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type rangeOption struct {
+	lo, hi *int64
+}
+
+func (r rangeOption) String() string   { return fmt.Sprintf("%d:%d", *r.lo, *r.hi) }
+func (r rangeOption) Reset()           { *r.lo, *r.hi = 0, 0 }
+func (r rangeOption) Get() interface{} { return [2]int64{*r.lo, *r.hi} }
+
+// jsonValue saves the range in the options file as written on the commandline
+func (r rangeOption) jsonValue() interface{} { return r.String() }
+
+func (r rangeOption) Set(s string) error {
+	first, last := s, s
+	if i := strings.Index(s, ":"); i >= 0 {
+		first, last = s[:i], s[i+1:]
+	} else if i := strings.Index(strings.TrimPrefix(s, "-"), "-"); i >= 0 {
+		i += len(s) - len(strings.TrimPrefix(s, "-")) // A dash at the start is the sign of the start value
+		first, last = s[:i], s[i+1:]
+	}
+	lo, err := strconv.ParseInt(strings.TrimSpace(first), 0, 64)
+	if err != nil {
+		return err
+	}
+	hi, err := strconv.ParseInt(strings.TrimSpace(last), 0, 64)
+	if err != nil {
+		return err
+	}
+	if lo > hi {
+		return fmt.Errorf(messages.InvalidRange, lo, hi)
+	}
+	*r.lo, *r.hi = lo, hi
+	return nil
+}
+
+// RangeOption adds an integer range option with the specified name, command group, help text, variable pointers and
+// flags. The range is written as start:end or start-end, with values parsed like IntOption, and a single value sets
+// both start and end. The start must not be greater than the end.
+//
+//	var firstPort, lastPort int64
+//	cmdparse.RangeOption("ports", "", "<first:last>", "Ports to listen on", &firstPort, &lastPort, cmdparse.Preference)
+//
+//	mytool -ports=8000-8010 serve
+func RangeOption(name string, cmd string, format string, help string, lo *int64, hi *int64, flags int) *CmdOption {
+	return addOption(name, cmd, format, help, rangeOption{lo, hi}, flags)
+}
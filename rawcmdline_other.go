@@ -0,0 +1,13 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build !windows
+// +build !windows
+
+package cmdparser
+
+// rawCommandLine is only available on Windows, other platforms pass the arguments to the process as they are
+func rawCommandLine() (string, bool) {
+	return "", false
+}
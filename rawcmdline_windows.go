@@ -0,0 +1,26 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build windows
+// +build windows
+
+package cmdparser
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// rawCommandLine returns the commandline of the process as returned by GetCommandLineW
+func rawCommandLine() (string, bool) {
+	p := syscall.GetCommandLine()
+	if p == nil {
+		return "", false
+	}
+	var line []uint16
+	for ptr := unsafe.Pointer(p); *(*uint16)(ptr) != 0; ptr = unsafe.Pointer(uintptr(ptr) + 2) {
+		line = append(line, *(*uint16)(ptr))
+	}
+	return syscall.UTF16ToString(line), true
+}
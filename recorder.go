@@ -0,0 +1,38 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+var usageRecorder func(command string, optionsSet []string)
+
+// SetUsageRecorder sets a function that is called after a command function has returned, with the name of the
+// command and the names of the options specified on the commandline in the order they were first specified. Option
+// values are never passed, which makes it possible to collect anonymous feature usage statistics without changing
+// every command. The command name is blank for the default command. Setting nil turns recording off.
+//
+//	cmdparse.SetUsageRecorder(func(command string, optionsSet []string) {
+//	  metrics.Count("command." + command)
+//	  for _, o := range optionsSet {
+//	    metrics.Count("option." + o)
+//	  }
+//	})
+func SetUsageRecorder(f func(command string, optionsSet []string)) {
+	usageRecorder = f
+}
+
+// recordUsage calls the usage recorder with the command and options of the result
+func (r *ParseResult) recordUsage() {
+	if usageRecorder == nil || r.Command == nil {
+		return
+	}
+	var names []string
+	seen := make(map[string]bool)
+	for _, o := range r.Occurrences {
+		if !seen[o.Option] {
+			seen[o.Option] = true
+			names = append(names, o.Option)
+		}
+	}
+	usageRecorder(r.Command.Command, names)
+}
@@ -0,0 +1,145 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// WatchInterval is how often WatchOptionsFile checks the options file for changes
+var WatchInterval = 2 * time.Second
+
+// ReloadOptions loads the options file again so that long running applications can pick up preference changes
+// without restarting. Options set on the commandline or by the application keep their values, options that were
+// loaded from the file but are no longer in it return to their defaults. If the file cannot be loaded all options
+// keep their current values. OnChange hooks are called for reloaded options.
+func ReloadOptions() error {
+	parseMutex.Lock()
+	defer parseMutex.Unlock()
+	defer deferDerived()()
+
+	if OptionsFile == "" {
+		return errors.New(messages.NoOptionsFile)
+	}
+	saved := snapshotValues()
+	var kept []optionState
+	for _, s := range saved {
+		if s.source == SourceOptionsFile {
+			s.option.setString(s.option.Default)
+			s.option.source = SourceDefault
+		} else if s.source != SourceDefault {
+			kept = append(kept, s)
+		}
+	}
+	if err := loadOptions(OptionsFile); err != nil {
+		restoreValues(saved)
+		return err
+	}
+	restoreValues(kept)
+	return nil
+}
+
+// OptionSnapshot holds the values of all options and where they were set from, see Snapshot
+type OptionSnapshot struct {
+	states []optionState
+}
+
+// Snapshot returns the current values of all options, so that they can be restored with Restore. Applications that
+// apply configuration in several steps use it to roll back to the last good configuration when a step fails.
+//
+//	good := cmdparse.Snapshot()
+//	if err := applyConfig(); err != nil {
+//	  cmdparse.Restore(good)
+//	}
+func Snapshot() *OptionSnapshot {
+	parseMutex.Lock()
+	defer parseMutex.Unlock()
+	return &OptionSnapshot{states: snapshotValues()}
+}
+
+// Restore sets all options in the snapshot back to their values and sources when the snapshot was taken. OnChange
+// hooks are called for the options that change. Options added after the snapshot was taken keep their values.
+func Restore(snapshot *OptionSnapshot) {
+	parseMutex.Lock()
+	defer parseMutex.Unlock()
+	defer deferDerived()()
+	restoreValues(snapshot.states)
+}
+
+// WithOverrides sets the named options to the values in overrides, calls f and restores all options to their
+// previous values when f returns, also if f panics. Values have the same format as on the commandline and replace
+// the whole value of list options. If an option does not exist or a value is invalid, f is not called and the
+// error is returned. WithOverrides makes it possible for a command to run the function of another command with
+// tweaked settings. OnChange hooks are called both when the values are set and when they are restored.
+//
+//	err := cmdparse.WithOverrides(map[string]string{"dry-run": "true", "retries": "0"}, push)
+func WithOverrides(overrides map[string]string, f func()) error {
+	var names []string
+	for name := range overrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	saved := snapshotValues()
+	for _, name := range names {
+		o := findOption(name)
+		if o == nil {
+			restoreValues(saved)
+			return fmt.Errorf(messages.InvalidOption, displayPrefix+name)
+		}
+		if o.accumulates() {
+			o.Value.Reset()
+		}
+		if err := o.apply(overrides[name], SourceProgram); err != nil {
+			restoreValues(saved)
+			return o.invalidValue(name, overrides[name], err)
+		}
+	}
+	defer restoreValues(saved)
+	f()
+	return nil
+}
+
+// WatchOptionsFile checks the options file for changes every WatchInterval and calls ReloadOptions when it has
+// changed, followed by onChange if the reload succeeded. Reload errors are reported through the warning handler.
+// Option variables are changed from the watching goroutine, so the application must synchronize its access to them,
+// for example by copying values in onChange while holding a lock. The returned function stops watching.
+//
+//	stop := cmdparse.WatchOptionsFile(func() {
+//	  log.Printf("Options reloaded, log level is now %s", logLevel)
+//	})
+//	defer stop()
+func WatchOptionsFile(onChange func()) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		last, _ := optionsStore.Load(OptionsFile)
+		ticker := time.NewTicker(WatchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+			data, _ := optionsStore.Load(OptionsFile)
+			if bytes.Equal(data, last) {
+				continue
+			}
+			last = data
+			if err := ReloadOptions(); err != nil {
+				warn(WarningReload, "", messages.ReloadFailed, err.Error())
+			} else if onChange != nil {
+				onChange()
+			}
+		}
+	}()
+	return func() {
+		close(done)
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// AllowResponseFiles enables response files, every @filename argument on the commandline is replaced by the
+// arguments in the file. Arguments in the file are separated by whitespace or newlines and can be quoted with ' or ".
+// Outside of Windows a backslash escapes the next character. Response files can refer to other response files.
+// This avoids the commandline length limits on Windows for tools with long lists of arguments.
+//
+//	mytool copy @ignore-list.txt src dst
+var AllowResponseFiles bool
+
+const maxResponseFileDepth = 8 // Limit for response files referencing other response files
+
+// expandResponseFiles replaces @filename arguments with the arguments read from the file
+func expandResponseFiles(args []string) ([]string, error) {
+	for depth := 0; ; depth++ {
+		var expanded []string
+		var found bool
+		for i, a := range args {
+			if a == "--" {
+				expanded = append(expanded, args[i:]...)
+				break
+			} else if i == 0 || len(a) < 2 || a[0] != '@' {
+				expanded = append(expanded, a)
+				continue
+			}
+
+			if depth >= maxResponseFileDepth {
+				return nil, errors.New(messages.NestedResponseFiles)
+			}
+			data, err := ioutil.ReadFile(a[1:])
+			if err != nil {
+				return nil, fmt.Errorf(messages.ResponseFileError, a[1:], err.Error())
+			}
+			list, err := splitArgs(strings.TrimPrefix(string(data), "\ufeff"), os.PathSeparator != '\\')
+			if err != nil {
+				return nil, fmt.Errorf(messages.ResponseFileError, a[1:], err.Error())
+			}
+			expanded = append(expanded, list...)
+			found = true
+		}
+		if !found {
+			return expanded, nil
+		}
+		args = expanded
+	}
+}
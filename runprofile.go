@@ -0,0 +1,91 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// RunProfilesFile sets the filename (with full path) where run profiles are stored. Setting RunProfilesFile enables
+// the -save-profile=<name> and -run-profile=<name> flags.
+//
+// A run profile stores an entire invocation (command, options and arguments) under a name so that complex recurring
+// jobs can be replayed later. Options flagged Sensitive are never stored. When a profile is run, the stored arguments
+// are inserted in place of the -run-profile flag, so any options following it on the commandline override the profile.
+var RunProfilesFile string
+
+const maxProfileDepth = 8 // Limit for profiles referencing other profiles
+
+func loadRunProfiles() (map[string][]string, error) {
+	profiles := make(map[string][]string)
+	data, err := optionsStore.Load(RunProfilesFile)
+	if os.IsNotExist(err) {
+		return profiles, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return nil, fmt.Errorf(messages.RunProfilesError, RunProfilesFile, err.Error())
+	}
+	return profiles, nil
+}
+
+// expandRunProfiles replaces -run-profile=<name> flags with the arguments stored in the profile
+func expandRunProfiles(args []string) ([]string, error) {
+	var profiles map[string][]string
+	for depth := 0; ; depth++ {
+		var expanded []string
+		var found bool
+		for i, a := range args {
+			if a == "--" {
+				expanded = append(expanded, args[i:]...)
+				break
+			}
+			name, ok := builtinFlag(a, "run-profile")
+			if !ok || name == "" {
+				expanded = append(expanded, a)
+				continue
+			}
+
+			if depth >= maxProfileDepth {
+				return nil, errors.New(messages.NestedRunProfiles)
+			}
+			if profiles == nil {
+				var err error
+				if profiles, err = loadRunProfiles(); err != nil {
+					return nil, err
+				}
+			}
+			p, ok := profiles[name]
+			if !ok {
+				return nil, fmt.Errorf(messages.UnknownRunProfile, name)
+			}
+			expanded = append(expanded, p...)
+			found = true
+		}
+		if !found {
+			return expanded, nil
+		}
+		args = expanded
+	}
+}
+
+// saveRunProfile stores the invocation arguments under the profile name
+func saveRunProfile(name string, invocation []string) error {
+	profiles, err := loadRunProfiles()
+	if err != nil {
+		return err
+	}
+	profiles[name] = invocation
+
+	data, err := json.MarshalIndent(profiles, "", "\t")
+	if err != nil {
+		return err
+	}
+	return optionsStore.Save(RunProfilesFile, data, 0600)
+}
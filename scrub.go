@@ -0,0 +1,48 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"os"
+	"strings"
+)
+
+// ScrubSensitiveArgs overwrites the values of Sensitive options in the memory of the process arguments, so that a
+// password specified on the commandline does not linger in the output of ps and /proc. It is a best effort that
+// returns false on platforms where the arguments seen by other processes cannot be changed, like Windows, and it does
+// not remove the value from shell history. The values of the options are copied first so that they are not scrubbed
+// as well. Call ScrubSensitiveArgs directly after Parse, before argument strings are kept anywhere else, and only
+// when os.Args holds the arguments passed by the operating system.
+//
+//	cmdparse.StringOption("password", "", "<password>", "Password", &password, cmdparse.Sensitive)
+//	cmdparse.Parse()
+//	cmdparse.ScrubSensitiveArgs()
+func ScrubSensitiveArgs() bool {
+	if !canScrubArgs {
+		return false
+	}
+	for i := 1; i < len(os.Args); i++ {
+		if os.Args[i] == "--" {
+			break
+		}
+		name, ok := optionName(os.Args[i])
+		if !ok {
+			continue
+		}
+		pair := strings.SplitN(name, "=", 2)
+		o := findOption(pair[0])
+		if o == nil || o.Flags&Sensitive == 0 {
+			continue
+		}
+		o.setString(string([]byte(o.plainString())))
+		if len(pair) == 2 {
+			scrubString(os.Args[i][len(os.Args[i])-len(pair[1]):])
+		} else if takesValue(o) && i < len(os.Args)-1 && isValueArg(o, os.Args[i+1]) {
+			i++
+			scrubString(os.Args[i])
+		}
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// helpKeyword returns the argument following the help flag at index i of args if it is a keyword to search help for
+func helpKeyword(args []string, i int) (string, bool) {
+	if args[i] == "--help-full" || i == len(args)-1 || args[i+1] == "all" || args[i+1] == "--" {
+		return "", false
+	} else if _, option := optionName(args[i+1]); option {
+		return "", false
+	}
+	return args[i+1], true
+}
+
+// searchHelp prints the commands and visible options whose name, format or help text contains keyword, ignoring
+// case, with options grouped by command like in full help
+func searchHelp(keyword string) {
+	resolveDefaults()
+	colorOutput = useColor()
+	lower := strings.ToLower(keyword)
+	matches := func(texts ...string) bool {
+		for _, t := range texts {
+			if strings.Contains(strings.ToLower(t), lower) {
+				return true
+			}
+		}
+		return false
+	}
+
+	var found bool
+	for _, c := range sortedCommands() {
+		if c.Command != "" && c.available() && matches(c.Command, c.usage, c.Help, c.ShortHelp) {
+			if !found {
+				fmt.Fprintln(output, messages.Usage)
+				found = true
+			}
+			c.printUsage(true)
+		}
+	}
+
+	width := outputWidth()
+	groups := []string{""}
+	for _, c := range sortedCommands() {
+		if c.Command != "" && c.available() {
+			groups = append(groups, c.Command)
+		}
+	}
+	for _, g := range groups {
+		var printedHeader bool
+		for _, o := range sortedOptions() {
+			if o.Group != g || o.Flags&Hidden > 0 || !o.available() || !matches(o.Name, o.Format, o.Help, o.longHelp) {
+				continue
+			}
+			if !printedHeader {
+				if found {
+					fmt.Fprintln(output)
+				}
+				if g == "" {
+					fmt.Fprintln(output, messages.Options)
+				} else {
+					fmt.Fprintf(output, messages.CommandOptions+"\n", g)
+				}
+				printedHeader, found = true, true
+			}
+			name, text := optionHelp(o, true)
+			printHelpEntry(name, text, width)
+		}
+	}
+	if !found {
+		fmt.Fprintf(output, messages.NoHelpMatches+"\n", keyword)
+	}
+}
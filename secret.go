@@ -0,0 +1,103 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"sync"
+	"time"
+)
+
+// Secret holds the value of a SecretOption. The value is only available through Value, which records every read,
+// while printing a Secret with fmt or a logger shows ******** instead of the value. The Get method of the option Value
+// returns the *Secret as well, and its String method returns ********.
+type Secret struct {
+	name  string
+	value string
+}
+
+// SecretAccess describes a read of the value of a Secret
+type SecretAccess struct {
+	Option   string    // Name of the option
+	Location string    // Source file and line of the code that read the value
+	Time     time.Time // When the value was read
+}
+
+var secretAccesses []SecretAccess
+var secretMutex sync.Mutex
+
+// Value returns the secret value and records the read, see SecretAccesses
+func (s *Secret) Value() string {
+	recordAccess(s.name)
+	return s.value
+}
+
+// recordAccess records a read of the value of a Sensitive option by the code calling into this package
+func recordAccess(option string) {
+	secretMutex.Lock()
+	secretAccesses = append(secretAccesses, SecretAccess{Option: option, Location: callerLocation(), Time: time.Now()})
+	secretMutex.Unlock()
+}
+
+// String returns ******** so that the secret is not revealed by mistake when printed or logged
+func (s Secret) String() string {
+	return maskedValue
+}
+
+// GoString returns ******** so that the secret is not revealed when printed with %#v
+func (s Secret) GoString() string {
+	return maskedValue
+}
+
+// SecretAccesses returns every read of a Secret value in the order they happened, together with the reads of other
+// Sensitive options through the typed getters of CmdOption and when their values are saved to the options file.
+// Security conscious applications can use this to verify that secrets are only read where expected, for example in
+// tests. Help, -showconfig, -showoptions, traces, Options and ExportEnv show Sensitive values as ******** and do not
+// read them.
+func SecretAccesses() []SecretAccess {
+	secretMutex.Lock()
+	defer secretMutex.Unlock()
+	return append([]SecretAccess{}, secretAccesses...)
+}
+
+type secretOption struct {
+	secret *Secret
+}
+
+// String returns ******** like the Secret, the parser reads the value with plainString to save and restore it
+func (s secretOption) String() string   { return maskedValue }
+func (s secretOption) Reset()           { s.secret.value = "" }
+func (s secretOption) Get() interface{} { return s.secret }
+
+// jsonValue saves the secret value to the options file, the read is recorded by CmdOption.jsonValue
+func (s secretOption) jsonValue() interface{} { return s.secret.value }
+func (s secretOption) Set(v string) error {
+	s.secret.value = v
+	return nil
+}
+
+// SecretOption adds a Sensitive string option that can only be read through the Value method of the Secret, with
+// the specified name, command group, help text, variable pointer and flags. Reads are recorded and can be listed
+// with SecretAccesses, which helps verifying that passwords and keys are not logged or saved by mistake.
+//
+//	var password cmdparse.Secret
+//	cmdparse.SecretOption("password", "", "<password>", "Password", &password, cmdparse.Standard)
+//
+//	client.Login(user, password.Value())
+func SecretOption(name string, cmd string, format string, help string, variable *Secret, flags int) *CmdOption {
+	variable.name = name
+	o := addOption(name, cmd, format, help, secretOption{variable}, flags|Sensitive)
+	o.Default = variable.value
+	return o
+}
+
+// plainString returns the value of the option in the same format as Value.String(), except for secrets that are
+// returned as is instead of masked. It is used by the parser to compare, save and restore values, reads by the
+// application go through Secret.Value and are recorded.
+func (c *CmdOption) plainString() string {
+	if s, ok := c.Value.(secretOption); ok {
+		return s.secret.value
+	}
+	return c.Value.String()
+}
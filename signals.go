@@ -0,0 +1,123 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// ErrInterrupted is returned by RunWithSignals when the command was cancelled by SIGINT or SIGTERM
+var ErrInterrupted = errors.New("Interrupted")
+
+var runTimeout time.Duration // Value of the option added with TimeoutOption
+
+// CommandContext adds a command with a function that takes a context, with the specified name and help text. When
+// the command is run by RunWithSignals, the context is cancelled on SIGINT or SIGTERM and when the -timeout option
+// expires. When it is run by Parse or ParseArgs the context is never cancelled. The ParseResult of the parse that
+// runs the command is available from the context with ResultFromContext.
+//
+//	cmdparse.CommandContext("sync", "<path>", func(ctx context.Context) {
+//	  syncFolder(ctx, cmdparse.Args[0])
+//	})
+func CommandContext(cmd string, help string, function func(ctx context.Context)) *CmdCommand {
+	c := Command(cmd, help, nil)
+	c.location = callerLocation()
+	c.Function = func() { function(context.Background()) }
+	c.run = func(ctx context.Context) error {
+		function(ctx)
+		return nil
+	}
+	c.usesContext = true
+	return c
+}
+
+// TimeoutOption adds a global -timeout=<duration> option with the specified flags, that limits how long a command
+// added with CommandContext can run when started by RunWithSignals. A zero timeout, the default, means no limit.
+//
+//	cmdparse.TimeoutOption(cmdparse.Standard)
+//
+//	mytool -timeout=30s sync /data
+func TimeoutOption(flags int) *CmdOption {
+	return addOption("timeout", "", "<duration>", "Cancel the command if it has not finished within the duration", (*durationOption)(&runTimeout), flags)
+}
+
+type durationOption time.Duration
+
+func (d *durationOption) String() string         { return time.Duration(*d).String() }
+func (d *durationOption) Reset()                 { *d = 0 }
+func (d *durationOption) Get() interface{}       { return time.Duration(*d) }
+func (d *durationOption) jsonValue() interface{} { return d.String() }
+func (d *durationOption) Set(s string) error {
+	v, err := time.ParseDuration(s)
+	*d = durationOption(v)
+	return err
+}
+
+// RunWithSignals parses the commandline the same way as Parse and runs the command with a context derived from ctx.
+// For commands added with CommandContext, the context is cancelled on the first SIGINT or SIGTERM so that the command
+// can shut down gracefully, while a second signal exits the program immediately. It is also cancelled when the
+// -timeout option added with TimeoutOption expires. Other commands are run the same way as by Parse, without
+// signal handling. RunWithSignals returns ErrInterrupted if the command was cancelled by a signal and an error if
+// it timed out, after the command function has returned.
+//
+//	func main() {
+//	  if err := cmdparse.RunWithSignals(context.Background()); err != nil && err != cmdparse.ErrHelp {
+//	    fmt.Fprintln(os.Stderr, err)
+//	    os.Exit(1)
+//	  }
+//	}
+func RunWithSignals(ctx context.Context) error {
+	result, err := parseExclusive(argSource.Args(), parseCommandLine)
+	if err != nil || result.dispatch == nil {
+		return err
+	}
+	if result.Command == nil || !result.Command.usesContext {
+		return result.dispatch(ctx)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if runTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, runTimeout)
+		defer cancel()
+	}
+	interrupted, done := make(chan struct{}), make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	defer close(done)
+	go func() {
+		select {
+		case <-signals:
+		case <-done:
+			return
+		}
+		close(interrupted)
+		cancel()
+		select {
+		case <-signals:
+			os.Exit(130)
+		case <-done:
+		}
+	}()
+
+	err = result.dispatch(ctx)
+
+	select {
+	case <-interrupted:
+		return ErrInterrupted
+	default:
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf(messages.TimedOut, runTimeout)
+	}
+	return err
+}
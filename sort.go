@@ -0,0 +1,70 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import "sort"
+
+// SortOrder decides the order of options or commands in help
+type SortOrder struct {
+	alphabetical bool
+	options      func(a, b *CmdOption) bool
+	commands     func(a, b *CmdCommand) bool
+}
+
+// Orders for SetOptionSort and SetCommandSort
+var (
+	SortDeclared     = SortOrder{}                   // In the order they were added, which is the default
+	SortAlphabetical = SortOrder{alphabetical: true} // By name
+)
+
+// SortCustom returns an order for SetOptionSort that sorts options with less
+func SortCustom(less func(a, b *CmdOption) bool) SortOrder {
+	return SortOrder{options: less}
+}
+
+// SortCommandsCustom returns an order for SetCommandSort that sorts commands with less
+func SortCommandsCustom(less func(a, b *CmdCommand) bool) SortOrder {
+	return SortOrder{commands: less}
+}
+
+var optionSort, commandSort SortOrder
+
+// SetOptionSort sets the order of options in help. Sorting keeps help stable when options are added from the
+// init functions of several packages, where the order they are added in can change.
+//
+//	cmdparse.SetOptionSort(cmdparse.SortAlphabetical)
+//	cmdparse.SetOptionSort(cmdparse.SortCustom(func(a, b *cmdparse.CmdOption) bool {
+//	  return a.Flags&cmdparse.Required > b.Flags&cmdparse.Required
+//	}))
+func SetOptionSort(order SortOrder) {
+	optionSort = order
+}
+
+// SetCommandSort sets the order of commands in help, see SetOptionSort
+func SetCommandSort(order SortOrder) {
+	commandSort = order
+}
+
+// sortedOptions returns all options in help order
+func sortedOptions() []*CmdOption {
+	list := append([]*CmdOption{}, optionList...)
+	if optionSort.alphabetical {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	} else if optionSort.options != nil {
+		sort.SliceStable(list, func(i, j int) bool { return optionSort.options(list[i], list[j]) })
+	}
+	return list
+}
+
+// sortedCommands returns all commands in help order
+func sortedCommands() []*CmdCommand {
+	list := append([]*CmdCommand{}, commandList...)
+	if commandSort.alphabetical {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].Command < list[j].Command })
+	} else if commandSort.commands != nil {
+		sort.SliceStable(list, func(i, j int) bool { return commandSort.commands(list[i], list[j]) })
+	}
+	return list
+}
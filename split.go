@@ -0,0 +1,199 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SplitCommandLine splits a line into arguments using POSIX shell like rules. Arguments are separated by whitespace,
+// single quotes preserve everything literally, double quotes preserve everything except backslash escaped " and \,
+// and outside of quotes a backslash escapes the next character. An unterminated quote or escape is an error.
+// SplitCommandLine is used by ParseLine and can be used to split commandlines read from scripts or configuration.
+//
+//	args, err := cmdparse.SplitCommandLine(`copy -ignore '*.tmp' "my files" backup\ 2`)
+//	// ["copy" "-ignore" "*.tmp" "my files" "backup 2"]
+func SplitCommandLine(line string) ([]string, error) {
+	return splitArgs(line, true)
+}
+
+// splitArgs splits a line into arguments like SplitCommandLine, with backslash escapes turned off unless escapes is set
+func splitArgs(line string, escapes bool) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var inArg bool
+	var quote rune
+	var escape bool
+
+	for _, r := range line {
+		switch {
+		case escape:
+			if quote == '"' && r != '"' && r != '\\' {
+				arg.WriteRune('\\')
+			}
+			arg.WriteRune(r)
+			escape = false
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '\\' && quote != '\'' && escapes:
+			escape = true
+			inArg = true
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteRune(r)
+			inArg = true
+		}
+	}
+	if escape {
+		return nil, errors.New(messages.UnexpectedEndOfLine)
+	} else if quote != 0 {
+		return nil, fmt.Errorf(messages.UnterminatedQuote, quote)
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// splitList splits a list value on comma and the OS path list separator. Quoted parts are kept together and
+// outside of Windows a backslash escapes the next character. Empty elements are dropped.
+func splitList(value string) ([]string, error) {
+	var list []string
+	var item strings.Builder
+	var quote rune
+	var escape bool
+
+	for _, r := range value {
+		switch {
+		case escape:
+			item.WriteRune(r)
+			escape = false
+		case r == '\\' && os.PathSeparator != '\\' && quote != '\'':
+			escape = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				item.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',' || r == os.PathListSeparator:
+			if item.Len() > 0 {
+				list = append(list, item.String())
+			}
+			item.Reset()
+		default:
+			item.WriteRune(r)
+		}
+	}
+	if escape {
+		return nil, errors.New(messages.UnexpectedEndOfValue)
+	} else if quote != 0 {
+		return nil, fmt.Errorf(messages.UnterminatedQuote, quote)
+	}
+	if item.Len() > 0 {
+		list = append(list, item.String())
+	}
+	return list, nil
+}
+
+// SplitWindowsCommandLine splits a raw Windows commandline into arguments using the rules of the Microsoft C runtime.
+// The program name ends at the first whitespace or is quoted, without any escapes. Arguments are separated by
+// whitespace, double quotes group whitespace and "" inside quotes is a literal quote. Backslashes are literal unless
+// they precede a double quote, then each pair of backslashes is one backslash and an odd backslash escapes the quote.
+// Use it for commandlines written for Windows programs, like the arguments of a scheduled task.
+//
+//	args := cmdparse.SplitWindowsCommandLine(`mytool.exe copy "C:\My Files\" D:\Backup`)
+//	// ["mytool.exe" "copy" `C:\My Files\` `D:\Backup`]
+func SplitWindowsCommandLine(line string) []string {
+	var args []string
+	var arg strings.Builder
+	var i int
+
+	// Program name
+	if strings.HasPrefix(line, `"`) {
+		end := strings.IndexByte(line[1:], '"')
+		if end < 0 {
+			return []string{line[1:]}
+		}
+		args = append(args, line[1:end+1])
+		i = end + 2
+	} else {
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			return []string{line}
+		}
+		args = append(args, line[:end])
+		i = end
+	}
+
+	var inArg, quoted bool
+	for i < len(line) {
+		c := line[i]
+		switch {
+		case c == '\\':
+			backslashes := 0
+			for i < len(line) && line[i] == '\\' {
+				backslashes++
+				i++
+			}
+			if i < len(line) && line[i] == '"' {
+				arg.WriteString(strings.Repeat(`\`, backslashes/2))
+				if backslashes%2 == 1 {
+					arg.WriteByte('"')
+					i++
+				}
+			} else {
+				arg.WriteString(strings.Repeat(`\`, backslashes))
+			}
+			inArg = true
+			continue
+		case c == '"':
+			if quoted && i+1 < len(line) && line[i+1] == '"' {
+				arg.WriteByte('"')
+				i++
+			} else {
+				quoted = !quoted
+			}
+			inArg = true
+		case (c == ' ' || c == '\t') && !quoted:
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+		default:
+			arg.WriteByte(c)
+			inArg = true
+		}
+		i++
+	}
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args
+}
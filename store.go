@@ -0,0 +1,141 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// ArgSource provides the commandline parsed by Parse and ParseWithResult, including the program name
+type ArgSource interface {
+	Args() []string
+}
+
+// ArgSourceFunc is an adapter to use an ordinary function as an ArgSource
+type ArgSourceFunc func() []string
+
+// Args calls f()
+func (f ArgSourceFunc) Args() []string {
+	return f()
+}
+
+// OptionsStore reads and writes the options file and run profiles by name. Load must return an error for which
+// os.IsNotExist is true when there is nothing stored under the name.
+type OptionsStore interface {
+	Load(name string) ([]byte, error)
+	Save(name string, data []byte, perm os.FileMode) error
+}
+
+var argSource ArgSource = ArgSourceFunc(func() []string { return os.Args })
+var optionsStore = defaultOptionsStore()
+
+// SetArgSource sets where Parse and ParseWithResult get the commandline from, the default is os.Args.
+// This makes it possible to use the parser in plugins or js/wasm applications where arguments come from elsewhere.
+//
+//	cmdparse.SetArgSource(cmdparse.ArgSourceFunc(func() []string { return []string{"app", "-verbose"} }))
+func SetArgSource(s ArgSource) {
+	argSource = s
+}
+
+// UseRawCommandLine makes Parse and ParseWithResult read the commandline of the process with GetCommandLineW on
+// Windows and split it with the quoting rules of the Microsoft C runtime, instead of using os.Args. Use it when the
+// program is started by tools that quote arguments for C programs, so that values with spaces, quotes and trailing
+// backslashes arrive exactly as intended. On other platforms the arguments are passed to the process already split
+// and os.Args is used.
+func UseRawCommandLine() {
+	argSource = ArgSourceFunc(func() []string {
+		if line, ok := rawCommandLine(); ok {
+			return SplitWindowsCommandLine(line)
+		}
+		return os.Args
+	})
+}
+
+// SetOptionsStore sets where the options file and run profiles are loaded from and saved to. The default is the
+// filesystem, except when built for js/wasm where the browser localStorage is used with the file names as keys.
+func SetOptionsStore(s OptionsStore) {
+	optionsStore = s
+}
+
+var optionsDirMode, optionsFileMode os.FileMode = 0700, 0700
+
+// SetOptionsFileMode sets the permissions of folders created for the options file and run profiles, and of a new
+// options file, the default is 0700 for both. A new options file that contains Sensitive options is only readable and
+// writable by the owner. An existing options file keeps its permissions and owner when it is saved.
+//
+//	cmdparse.SetOptionsFileMode(0755, 0644)
+func SetOptionsFileMode(dirMode os.FileMode, fileMode os.FileMode) {
+	optionsDirMode, optionsFileMode = dirMode, fileMode
+}
+
+// optionsFilePerm returns the permissions of a new options file with the current options
+func optionsFilePerm() os.FileMode {
+	for _, o := range optionList {
+		if o.Flags&Sensitive > 0 && o.persisted() {
+			return optionsFileMode & 0600
+		}
+	}
+	return optionsFileMode
+}
+
+// fileStore stores options in files
+type fileStore struct{}
+
+func (fileStore) Load(name string) ([]byte, error) {
+	return ioutil.ReadFile(name)
+}
+
+func (fileStore) Save(name string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(name), optionsDirMode); err != nil {
+		return err
+	}
+	return writeFileAtomic(name, data, perm)
+}
+
+var permissionsWarned = make(map[string]bool) // Options files that have been reported by checkPermissions
+
+// checkPermissions warns once per options file if the file contains values of Sensitive or byte options and can be
+// read or written by other users than the owner, like ssh does for private keys. With SetStrictOptionsFile the file is
+// not loaded and an error is returned instead.
+func checkPermissions(name string, optionMap map[string]interface{}) error {
+	if _, ok := optionsStore.(fileStore); !ok {
+		return nil
+	}
+	var sensitive string
+	for _, o := range optionList {
+		if _, ok := optionMap[o.key()]; ok && o.sensitive() {
+			sensitive = o.Name
+			break
+		}
+	}
+	if sensitive == "" {
+		return nil
+	}
+	perm, shared := sharedPerm(name)
+	if !shared {
+		return nil
+	}
+	message := fmt.Sprintf(messages.SharedSensitiveFile, name, sensitive, perm)
+	if strictOptionsFile {
+		return errors.New(message)
+	} else if !permissionsWarned[name] {
+		permissionsWarned[name] = true
+		warn(WarningPermissions, sensitive, "%s", message)
+	}
+	return nil
+}
+
+// sensitive returns true if the option is flagged Sensitive or holds binary data like keys
+func (o *CmdOption) sensitive() bool {
+	switch o.Value.(type) {
+	case *byteOption, encodedByteOption:
+		return true
+	}
+	return o.Flags&Sensitive > 0
+}
@@ -0,0 +1,49 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build js
+// +build js
+
+package cmdparser
+
+import (
+	"os"
+	"syscall/js"
+)
+
+func defaultOptionsStore() OptionsStore {
+	return localStorage{}
+}
+
+// localStorage stores options in the browser localStorage, using the name as key
+type localStorage struct{}
+
+func (localStorage) storage() (js.Value, error) {
+	s := js.Global().Get("localStorage")
+	if s.IsUndefined() || s.IsNull() {
+		return s, &os.PathError{Op: "open", Path: "localStorage", Err: os.ErrNotExist}
+	}
+	return s, nil
+}
+
+func (l localStorage) Load(name string) ([]byte, error) {
+	s, err := l.storage()
+	if err != nil {
+		return nil, err
+	}
+	v := s.Call("getItem", name)
+	if v.IsNull() {
+		return nil, &os.PathError{Op: "load", Path: name, Err: os.ErrNotExist}
+	}
+	return []byte(v.String()), nil
+}
+
+func (l localStorage) Save(name string, data []byte, perm os.FileMode) error {
+	s, err := l.storage()
+	if err != nil {
+		return err
+	}
+	s.Call("setItem", name, string(data))
+	return nil
+}
@@ -0,0 +1,12 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build !js
+// +build !js
+
+package cmdparser
+
+func defaultOptionsStore() OptionsStore {
+	return fileStore{}
+}
@@ -0,0 +1,91 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	helpIndent   = 8  // Indentation of help text below option names
+	defaultWidth = 80 // Width used for wrapping when the terminal width cannot be detected
+)
+
+const (
+	colorReset    = "\x1b[0m"
+	colorBold     = "\x1b[1m"
+	colorRed      = "\x1b[31m"
+	colorRequired = colorBold + colorRed
+)
+
+var colorDisabled bool
+
+var colorOutput bool // Result of useColor, set once per help text instead of checking the terminal for every word
+
+// DisableColor turns off color highlighting in help output. Color is also disabled if the NO_COLOR
+// environment variable is set or if output is not a terminal.
+func DisableColor() {
+	colorDisabled = true
+}
+
+// useColor returns true if ANSI color sequences should be written to output
+func useColor() bool {
+	if colorDisabled || output != os.Stdout || os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return terminalWidth() > 0 && enableColorOutput()
+}
+
+// colorize wraps text in the ANSI color sequence if color output is enabled, colorOutput must be set with useColor
+// before the text is written
+func colorize(color string, text string) string {
+	if text == "" || !colorOutput {
+		return text
+	}
+	return color + text + colorReset
+}
+
+// outputWidth returns the width to wrap help text at, using the COLUMNS environment variable or the
+// detected terminal width when writing to stdout
+func outputWidth() int {
+	if output != os.Stdout {
+		return defaultWidth
+	} else if c, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && c > 0 {
+		return c
+	} else if w := terminalWidth(); w > 0 {
+		return w
+	}
+	return defaultWidth
+}
+
+// wrapText word-wraps text to the specified width with every line indented by indent spaces.
+// Words longer than the available space are kept on a line of their own.
+func wrapText(text string, indent int, width int) string {
+	prefix := strings.Repeat(" ", indent)
+	space := width - indent - 1
+	if space < 20 {
+		space = 20
+	}
+
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			if line != "" && utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) > space {
+				lines = append(lines, prefix+line)
+				line = ""
+			}
+			if line != "" {
+				line += " "
+			}
+			line += word
+		}
+		lines = append(lines, prefix+line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,17 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package cmdparser
+
+// terminalWidth is not supported on this platform, help text is wrapped at the default width
+func terminalWidth() int {
+	return 0
+}
+
+func enableColorOutput() bool {
+	return false
+}
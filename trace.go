@@ -0,0 +1,55 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+// ParseEventType describes a decision made by the parser in a ParseEvent
+type ParseEventType int
+
+// Parse decisions reported to the trace function
+const (
+	TraceOptionSet      ParseEventType = iota // Option was set, Name, Value and Source tell which, to what and from where
+	TraceCommandMatched                       // Command was matched, Name is blank for the default command
+	TraceArgSkipped                           // Argument Value at Index was not parsed as an option and is left in Args
+)
+
+func (t ParseEventType) String() string {
+	switch t {
+	case TraceOptionSet:
+		return "option set"
+	case TraceCommandMatched:
+		return "command matched"
+	case TraceArgSkipped:
+		return "arg skipped"
+	}
+	return "unknown"
+}
+
+// ParseEvent describes a single decision made by the parser
+type ParseEvent struct {
+	Type   ParseEventType // What was decided
+	Name   string         // Name of the option or command
+	Value  string         // Value of the option or the skipped argument
+	Source OptionSource   // Where the option value came from
+	Index  int            // Index of the commandline argument, 0 if the event is not caused by an argument
+}
+
+var traceFunc func(event ParseEvent)
+
+// SetTrace sets a function that is called for every option set, command matched and argument skipped while
+// parsing. This makes it possible to see how the options file, application and commandline take precedence
+// over each other. Setting nil turns tracing off.
+//
+//	cmdparse.SetTrace(func(ev cmdparse.ParseEvent) {
+//	  fmt.Fprintf(os.Stderr, "trace: %s %s=%q (%s)\n", ev.Type, ev.Name, ev.Value, ev.Source)
+//	})
+func SetTrace(f func(event ParseEvent)) {
+	traceFunc = f
+}
+
+func trace(ev ParseEvent) {
+	if traceFunc != nil {
+		traceFunc(ev)
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import "fmt"
+
+// Validate checks the commands and options that have been added for mistakes in the definition, like options in a
+// group that has no command, Required options that are Hidden, options without help, defaults that are not valid
+// values for their own option, options with the same name but different formats, and references to options that do
+// not exist. Validate returns nil if no problems are found. It is meant to be run by a test or a CI step rather than
+// on every start.
+//
+//	func TestCommandline(t *testing.T) {
+//	  setupCommandline()
+//	  for _, err := range cmdparse.Validate() {
+//	    t.Error(err)
+//	  }
+//	}
+func Validate() []error {
+	parseMutex.Lock()
+	defer parseMutex.Unlock()
+	invalidateIndex()
+
+	var problems []error
+	report := func(location string, format string, a ...interface{}) {
+		problems = append(problems, fmt.Errorf("%s: %s", location, fmt.Sprintf(format, a...)))
+	}
+
+	formats := make(map[string]*CmdOption)
+	for _, o := range optionList {
+		if o.Group != "" && findCommand(o.Group) == nil {
+			report(o.location, "Option -%s is in group \"%s\" that has no command", o.Name, o.Group)
+		}
+		if o.Flags&Required > 0 && o.Flags&Hidden > 0 {
+			report(o.location, "Option -%s is both Required and Hidden", o.Name)
+		}
+		if o.Help == "" {
+			report(o.location, "Option -%s has no help text", o.Name)
+		}
+		if o.Default != "" && !o.accumulates() {
+			previous := o.plainString()
+			if err := o.set(o.Default); err != nil {
+				report(o.location, "Default value \"%s\" of option -%s is not valid (%s)", o.Default, o.Name, err.Error())
+			}
+			o.setString(previous)
+		}
+		if f, exists := formats[o.Name]; exists && f.Format != o.Format {
+			report(o.location, "Option -%s has format %s while the option with the same name at %s has format %s", o.Name,
+				o.Format, f.location, f.Format)
+		} else if !exists {
+			formats[o.Name] = o
+		}
+		for _, name := range o.derivedFrom {
+			if findOption(name) == nil {
+				report(o.location, "Option -%s is derived from option -%s that does not exist", o.Name, name)
+			}
+		}
+	}
+
+	for _, c := range commandList {
+		for _, name := range c.requiredOptions {
+			if findScopedOption(name, c) == nil {
+				report(c.location, "Command %s requires option -%s that does not exist", c.Command, name)
+			}
+		}
+	}
+	for _, g := range requiredGroups {
+		for _, name := range g.names {
+			if findOption(name) == nil {
+				report("RequireOneOf", "Option -%s does not exist", name)
+			}
+		}
+	}
+	return problems
+}
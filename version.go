@@ -0,0 +1,183 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version, Commit and BuildDate hold the build metadata displayed by the -version flag and the version command.
+// They are plain string variables so that they can be set at build time using -ldflags
+//
+//	go build -ldflags "-X github.com/fredli74/cmdparser.Version=1.2.0 -X github.com/fredli74/cmdparser.Commit=$(git rev-parse --short HEAD)"
+var (
+	Version   string // Version of the application, setting Version enables the version command
+	Commit    string // Source control revision the application was built from
+	BuildDate string // Date the application was built
+)
+
+// SetVersion sets the version, commit and build date metadata of the application.
+// Setting a version enables the -version flag and a version command (unless a command named version has been added)
+// that prints the structured version information. Specifying -version=json prints the same information as JSON.
+//
+//	cmdparse.SetVersion("1.2.0", "a1b2c3d", "2016-03-01")
+func SetVersion(ver, commit, date string) {
+	Version, Commit, BuildDate = ver, commit, date
+}
+
+type versionInfo struct {
+	Name    string `json:"name"`
+	Title   string `json:"title,omitempty"`
+	Version string `json:"version,omitempty"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"date,omitempty"`
+}
+
+func hasVersion() bool {
+	return Version != "" || Title != ""
+}
+
+// printVersion displays version information in the specified format ("" for text or "json")
+func printVersion(format string) error {
+	info := versionInfo{Name: commandName, Title: Title, Version: Version, Commit: Commit, Date: BuildDate}
+	switch format {
+	case "json":
+		js, err := json.MarshalIndent(info, "", "\t")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(output, string(js))
+	case "":
+		if Version == "" {
+			// Title-only applications print the title just like before
+			if Title != "" {
+				fmt.Fprintln(output, Title)
+			} else {
+				fmt.Fprintln(output, messages.NoVersion)
+			}
+			return nil
+		}
+		fmt.Fprintf(output, "%s version %s\n", info.Name, info.Version)
+		if info.Commit != "" {
+			fmt.Fprintf(output, "  commit: %s\n", info.Commit)
+		}
+		if info.Date != "" {
+			fmt.Fprintf(output, "  built:  %s\n", info.Date)
+		}
+	default:
+		return fmt.Errorf(messages.InvalidVersionFormat, format)
+	}
+	return nil
+}
+
+var updateChecker func(currentVersion string) (latest string, url string, err error)
+
+// RegisterUpdateChecker sets a function that returns the latest available version of the application and where to
+// get it. Registering a checker enables the -check-update flag, which calls the checker with Version and prints if a
+// newer version is available. The checker is never called unless -check-update is specified.
+//
+//	cmdparse.RegisterUpdateChecker(func(current string) (string, string, error) {
+//	  release, err := fetchLatestRelease("https://api.example.com/mytool/releases/latest")
+//	  if err != nil {
+//	    return "", "", err
+//	  }
+//	  return release.Version, release.DownloadURL, nil
+//	})
+func RegisterUpdateChecker(f func(currentVersion string) (latest string, url string, err error)) {
+	updateChecker = f
+}
+
+// checkUpdate calls the update checker and prints if a newer version is available
+func checkUpdate() error {
+	latest, url, err := updateChecker(Version)
+	if err != nil {
+		return fmt.Errorf(messages.UpdateCheckFailed, err.Error())
+	}
+	if latest == "" || compareVersions(latest, Version) <= 0 {
+		fmt.Fprintf(output, messages.UpToDate+"\n", commandName, Version)
+		return nil
+	}
+	fmt.Fprintf(output, messages.UpdateAvailable+"\n", latest, Version)
+	if url != "" {
+		fmt.Fprintln(output, "  "+url)
+	}
+	return nil
+}
+
+// compareVersions compares two versions like v1.10.2 with semantic versioning precedence: the fields of the version
+// are compared as numbers, a pre-release like 1.2.0-beta.2 is older than the release 1.2.0 and build metadata after +
+// is ignored. It returns a negative number if a is older than b, zero if they are the same and a positive number if a
+// is newer.
+func compareVersions(a string, b string) int {
+	coreA, preA := splitVersion(a)
+	coreB, preB := splitVersion(b)
+	for i := 0; i < len(coreA) || i < len(coreB); i++ {
+		fa, fb := "0", "0" // Missing fields count as 0, so 1.2 is the same as 1.2.0
+		if i < len(coreA) {
+			fa = coreA[i]
+		}
+		if i < len(coreB) {
+			fb = coreB[i]
+		}
+		if c := compareIdentifiers(fa, fb); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case len(preA) == 0 && len(preB) == 0:
+		return 0
+	case len(preA) == 0:
+		return 1
+	case len(preB) == 0:
+		return -1
+	}
+	for i := 0; i < len(preA) && i < len(preB); i++ {
+		if c := compareIdentifiers(preA[i], preB[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(preA), len(preB))
+}
+
+// splitVersion returns the dot separated fields of the version and of its pre-release, without build metadata
+func splitVersion(v string) (core []string, pre []string) {
+	v = strings.TrimPrefix(v, "v")
+	if i := strings.Index(v, "+"); i >= 0 {
+		v = v[:i]
+	}
+	if i := strings.Index(v, "-"); i >= 0 {
+		pre = strings.Split(v[i+1:], ".")
+		v = v[:i]
+	}
+	return strings.Split(v, "."), pre
+}
+
+// compareIdentifiers compares two version fields, numerically if both are numbers. A number is older than text.
+func compareIdentifiers(a string, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+func compareInts(a int, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,59 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected int
+	}{
+		{"1.2.0", "1.2.0", 0},
+		{"v1.2.0", "1.2.0", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.10.0", "1.9.0", 1},
+		{"1.2.0", "1.2.1", -1},
+		{"2.0.0", "1.99.99", 1},
+		{"1.2.0-beta", "1.2.0", -1},
+		{"1.2.0", "1.2.0-beta", 1},
+		{"1.2.0-rc.1", "1.2.0-beta.2", 1},
+		{"1.0.0-alpha", "1.0.0-alpha.1", -1},
+		{"1.0.0-alpha.1", "1.0.0-alpha.beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-rc.1", "1.0.0", -1},
+		{"1.2.0+build.5", "1.2.0+build.7", 0},
+		{"1.2.0-beta+build.5", "1.2.0-beta", 0},
+		{"1.2.1-beta", "1.2.0", 1},
+	}
+	for _, test := range tests {
+		got := compareVersions(test.a, test.b)
+		if (got < 0 && test.expected >= 0) || (got > 0 && test.expected <= 0) || (got == 0 && test.expected != 0) {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", test.a, test.b, got, test.expected)
+		}
+	}
+}
+
+func TestCheckUpdateFromPreRelease(t *testing.T) {
+	Reset()
+	var out bytes.Buffer
+	SetOutput(&out)
+	Version = "1.2.0-beta"
+	defer func() { Version = "" }()
+	RegisterUpdateChecker(func(current string) (string, string, error) {
+		return "1.2.0", "", nil
+	})
+	if err := checkUpdate(); err != nil {
+		t.Fatal(err)
+	}
+	if expected := fmt.Sprintf(messages.UpdateAvailable, "1.2.0", "1.2.0-beta"); !strings.Contains(out.String(), expected) {
+		t.Errorf("got %q, expected %q", out.String(), expected)
+	}
+}
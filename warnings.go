@@ -0,0 +1,86 @@
+// Copyright 2015-2016 Fredrik Lidström. All rights reserved.
+// Use of this source code is governed by the standard MIT License (MIT)
+// that can be found in the LICENSE file.
+
+package cmdparser
+
+import (
+	"fmt"
+	"sync"
+)
+
+// WarningType describes the kind of problem in a Warning
+type WarningType int
+
+// Kinds of non-fatal problems found while parsing
+const (
+	WarningUnknownOption WarningType = iota // Options or policy file contains a key that does not match any option
+	WarningDeprecated                       // Deprecated option was used
+	WarningDuplicate                        // Option was specified more than once on the commandline, the last value is used
+	WarningReload                           // Options file could not be reloaded by WatchOptionsFile
+	WarningNormalizedKey                    // Options file key only matched an option when ignoring case, dashes and underscores
+	WarningRecent                           // Recent values of MRU options could not be saved to the options file
+	WarningPermissions                      // Options file with Sensitive values can be accessed by other users
+)
+
+func (t WarningType) String() string {
+	switch t {
+	case WarningUnknownOption:
+		return "unknown option"
+	case WarningDeprecated:
+		return "deprecated"
+	case WarningDuplicate:
+		return "duplicate"
+	case WarningReload:
+		return "reload"
+	case WarningNormalizedKey:
+		return "normalized key"
+	case WarningRecent:
+		return "recent values"
+	case WarningPermissions:
+		return "permissions"
+	}
+	return "unknown"
+}
+
+// Warning describes a non-fatal problem found while parsing
+type Warning struct {
+	Type    WarningType // Kind of problem
+	Option  string      // Name of the option the warning is about
+	Message string      // Description of the problem, as passed to the warning handler
+}
+
+func (w Warning) String() string {
+	return w.Message
+}
+
+var warningMutex sync.Mutex
+var warnings []Warning
+
+// Warnings returns the non-fatal problems found by the last parse, in the order they were found. Warnings are also
+// passed to the warning handler as they are found, see SetWarningHandler.
+//
+//	for _, w := range cmdparse.Warnings() {
+//	  log.Printf("%s: %s", w.Type, w.Message)
+//	}
+func Warnings() []Warning {
+	warningMutex.Lock()
+	defer warningMutex.Unlock()
+	return append([]Warning{}, warnings...)
+}
+
+func clearWarnings() {
+	warningMutex.Lock()
+	warnings = nil
+	warningMutex.Unlock()
+}
+
+func warn(t WarningType, option string, format string, a ...interface{}) {
+	w := Warning{Type: t, Option: option, Message: fmt.Sprintf(format, a...)}
+	warningMutex.Lock()
+	warnings = append(warnings, w)
+	warningMutex.Unlock()
+	if warningHandler != nil {
+		warningHandler(w.Message)
+	}
+}